@@ -0,0 +1,45 @@
+// Command reconcile checks cash balances, positions and tax lots against
+// the ledger and the transaction history and prints every break it finds.
+// With -correct it also corrects them. It exits with status 1 when breaks
+// remain uncorrected.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/reconcile"
+)
+
+func main() {
+	correct := flag.Bool("correct", false, "correct the breaks found")
+	flag.Parse()
+
+	db := config.ConnectDB()
+	defer db.Close()
+
+	report, err := reconcile.Run(db, *correct)
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	for _, b := range report.Breaks {
+		key := b.Currency
+		if b.Ticker != "" {
+			key = b.Ticker
+		}
+		status := "open"
+		if b.Corrected {
+			status = "corrected"
+		}
+		fmt.Printf("%-12s %-20s %-6s expected %s actual %s difference %s (%s)\n",
+			b.Kind, b.Username, key, b.Expected, b.Actual, b.Difference, status)
+	}
+	fmt.Printf("%d breaks, %d corrected\n", len(report.Breaks), report.Corrected)
+
+	if len(report.Breaks) > report.Corrected {
+		os.Exit(1)
+	}
+}
@@ -7,9 +7,11 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// ConnectionString locates the exchange database.
+const ConnectionString = "user=postgres dbname=stock_exchange_go password=postgres sslmode=disable"
+
 func ConnectDB() *sql.DB {
-	connStr := "user=postgres dbname=stock_exchange_go password=postgres sslmode=disable"
-	db, err := sql.Open("postgres", connStr)
+	db, err := sql.Open("postgres", ConnectionString)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCandles godoc
+// @Summary Get OHLCV candles of a stock
+// @Description Aggregates the ticker's recorded prices into open, high, low, close and volume bars of 1m, 1h or 1d, oldest first. Each bar starts at its time; daily bars follow calendar days in the exchange timezone. Prices and volumes are restated in current shares when the stock has split since. from and to take RFC 3339 timestamps or YYYY-MM-DD dates; to defaults to now and from to 500 bars before to. Bars without prices are left out, and at most 5000 bars may be requested at once.
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param ticker path string true "Stock Ticker"
+// @Param interval query string false "Bar size" Enums(1m, 1h, 1d) default(1m)
+// @Param from query string false "Start of the range, inclusive"
+// @Param to query string false "End of the range, exclusive"
+// @Success 200 {array} engine.Candle
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/stocks/{ticker}/candles [get]
+func GetCandles(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	interval := c.DefaultQuery("interval", "1m")
+
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := parseTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "to must be an RFC 3339 timestamp or a YYYY-MM-DD date"})
+			return
+		}
+		to = parsed
+	}
+
+	from, err := engine.CandleRange(interval, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Interval must be 1m, 1h or 1d"})
+		return
+	}
+	if value := c.Query("from"); value != "" {
+		from, err = parseTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must be an RFC 3339 timestamp or a YYYY-MM-DD date"})
+			return
+		}
+	}
+
+	candles, err := engine.Candles(db, c.Param("ticker"), interval, from, to)
+	switch {
+	case errors.Is(err, engine.ErrInvalidInterval):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must be before to, and the range may cover at most 5000 bars"})
+		return
+	case errors.Is(err, engine.ErrStockNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Stock not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve candles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, candles)
+}
+
+// parseTime accepts an RFC 3339 timestamp or a YYYY-MM-DD date, read as
+// midnight UTC.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
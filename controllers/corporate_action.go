@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/utils/decimal"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CorporateActionRequest struct {
+	Ticker           string           `json:"ticker" example:"AAPL"`
+	ActionType       string           `json:"action_type" example:"SPLIT" enums:"SPLIT,DIVIDEND"`
+	SplitFrom        int              `json:"split_from" example:"1"`
+	SplitTo          int              `json:"split_to" example:"4"`
+	DividendPerShare *decimal.Decimal `json:"dividend_per_share" example:"0.24"`
+	ExDate           string           `json:"ex_date" example:"2025-01-15"`
+	RecordDate       string           `json:"record_date" example:"2025-01-15"`
+	PayDate          string           `json:"pay_date" example:"2025-01-31"`
+}
+
+type ProcessCorporateActionsResponse struct {
+	Processed int `json:"processed"`
+}
+
+// AnnounceCorporateAction godoc
+// @Summary Announce a split or dividend
+// @Description Schedules a corporate action. A SPLIT turns every split_from shares into split_to shares (4-for-1 is split_from 1, split_to 4; a 1-for-10 reverse split is split_from 10, split_to 1). Before the open on the ex-date, positions, the filled and open shares of open orders and the stock's prices are restated in post-split shares, and fractional shares are cashed out at the adjusted price. A DIVIDEND pays dividend_per_share, in the stock's currency, on every share whose trade has settled by the end of the record date, recorded once the record date begins; short holders are charged it. Dividends are credited on the pay date. Dates are YYYY-MM-DD in the exchange timezone, ordered ex_date <= record_date <= pay_date, and ex_date must be after today. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param action body CorporateActionRequest true "Corporate action"
+// @Success 201 {object} engine.CorporateAction
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/corporate-actions [post]
+func AnnounceCorporateAction(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input CorporateActionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	action := engine.CorporateAction{
+		Ticker:           input.Ticker,
+		ActionType:       input.ActionType,
+		SplitFrom:        input.SplitFrom,
+		SplitTo:          input.SplitTo,
+		DividendPerShare: input.DividendPerShare,
+		ExDate:           input.ExDate,
+		RecordDate:       input.RecordDate,
+		PayDate:          input.PayDate,
+		AnnouncedBy:      c.GetString("username"),
+	}
+	err := engine.AnnounceCorporateAction(db, &action, time.Now())
+	switch {
+	case errors.Is(err, engine.ErrInvalidAction):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, engine.ErrStockNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Stock not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to announce corporate action"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, action)
+}
+
+// GetCorporateActions godoc
+// @Summary List corporate actions of a stock
+// @Description Retrieves the splits and dividends announced for the ticker, latest ex-date first.
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param ticker path string true "Stock Ticker"
+// @Success 200 {array} engine.CorporateAction
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/stocks/{ticker}/corporate-actions [get]
+func GetCorporateActions(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	actions, err := engine.CorporateActions(db, c.Param("ticker"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve corporate actions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, actions)
+}
+
+// CancelCorporateAction godoc
+// @Summary Cancel a corporate action
+// @Description Withdraws a SCHEDULED corporate action: a split before its ex-date, a dividend before its record date. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Corporate action ID"
+// @Success 200 {object} engine.CorporateAction
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/corporate-actions/{id} [delete]
+func CancelCorporateAction(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid corporate action ID"})
+		return
+	}
+
+	action, err := engine.CancelCorporateAction(db, id)
+	switch {
+	case errors.Is(err, engine.ErrActionNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Corporate action not found"})
+		return
+	case errors.Is(err, engine.ErrActionClosed):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Corporate action can no longer be cancelled"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to cancel corporate action"})
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+// ProcessCorporateActions godoc
+// @Summary Process due corporate actions now
+// @Description Applies every scheduled split whose ex-date has been reached, records the holders of every scheduled dividend whose record date has been reached and pays every dividend whose pay date has been reached, without waiting for the background processor. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} ProcessCorporateActionsResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/corporate-actions/process [post]
+func ProcessCorporateActions(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	processed, err := engine.ProcessCorporateActions(db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to process corporate actions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ProcessCorporateActionsResponse{Processed: processed})
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/feed"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// eventBatch is how many feed events are read from the database at once.
+	eventBatch = 100
+	// eventHeartbeat is how often an idle event stream sends a comment to
+	// keep proxies from closing it.
+	eventHeartbeat = 15 * time.Second
+)
+
+// StreamEvents godoc
+// @Summary Stream the caller's order, fill and balance events
+// @Description Opens a Server-Sent Events stream of the feed of the trading account linked to the caller's login. ORDER events carry the new state of one of their orders, FILL events one side of a trade they took part in, and BALANCE events a change to their cash in one currency with the balance after it. Each event's id is its seq, numbered from 1 per user in commit order, and its data is the feed event as JSON. Send the Last-Event-ID header, or the last_event_id query parameter, to resume after that event; without it the stream starts with the next event. A comment is sent every 15 seconds while the stream is idle.
+// @Tags User
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "Resume after this event"
+// @Param last_event_id query int false "Resume after this event"
+// @Success 200 {array} feed.Event
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/events [get]
+func StreamEvents(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	userID, err := engine.LinkedUser(db, c.GetString("username"))
+	if errors.Is(err, engine.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No trading account is linked to this login"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve user"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	// Readers subscribe before reading the feed so that no event committed
+	// in between goes unannounced.
+	wake, unsubscribe := feed.Subscribe(userID)
+	defer unsubscribe()
+
+	var seq int64
+	if lastEventID != "" {
+		seq, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || seq < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Last-Event-ID must be the id of an event"})
+			return
+		}
+	} else if seq, err = feed.LastSeq(db, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve events"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		for {
+			events, err := feed.Since(db, userID, seq, eventBatch)
+			if err != nil {
+				log.Printf("Failed to read events of user %d: %v", userID, err)
+				return
+			}
+			for _, event := range events {
+				data, err := json.Marshal(event)
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+				seq = event.Seq
+			}
+			c.Writer.Flush()
+			if len(events) < eventBatch {
+				break
+			}
+		}
+
+		// Notifications can be lost while the listener reconnects, so the
+		// feed is also read on every heartbeat.
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-wake:
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+	}
+}
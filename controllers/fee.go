@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// perSharePlaces is how many decimal places fee_schedules.per_share
+	// keeps; the database would round away any more.
+	perSharePlaces = 4
+	// percentagePlaces is how many decimal places fee_schedules.percentage
+	// keeps.
+	percentagePlaces = 6
+)
+
+type FeeScheduleRequest struct {
+	Liquidity        string          `json:"liquidity" example:"TAKER" enums:"MAKER,TAKER"`
+	MinMonthlyVolume int             `json:"min_monthly_volume" example:"0"`
+	PerShare         decimal.Decimal `json:"per_share" example:"0.003"`
+	Percentage       decimal.Decimal `json:"percentage" example:"0"`
+	Flat             decimal.Decimal `json:"flat" example:"0"`
+}
+
+type FeeRevenueResponse struct {
+	Account  string          `json:"account"`
+	Balance  decimal.Decimal `json:"balance"`
+	Balances []ledger.Cash   `json:"balances"`
+}
+
+// GetFeeSchedules godoc
+// @Summary List the commission schedule
+// @Description Retrieves every fee tier. A fill is charged by the tier for its liquidity (MAKER or TAKER) with the highest min_monthly_volume the user has reached this calendar month; the fee is per_share * volume + percentage * notional + flat.
+// @Tags Fee
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.FeeSchedule
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/fees [get]
+func GetFeeSchedules(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	query := `
+		SELECT id, liquidity, min_monthly_volume, per_share, percentage, flat
+		FROM fee_schedules
+		ORDER BY liquidity, min_monthly_volume`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve fee schedule"})
+		return
+	}
+	defer rows.Close()
+
+	schedules := []models.FeeSchedule{}
+	for rows.Next() {
+		var schedule models.FeeSchedule
+		err := rows.Scan(&schedule.ID, &schedule.Liquidity, &schedule.MinMonthlyVolume, &schedule.PerShare, &schedule.Percentage, &schedule.Flat)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Error processing fee schedule"})
+			return
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Error processing fee schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// SetFeeSchedule godoc
+// @Summary Create or replace a fee tier
+// @Description Saves the fee tier for a liquidity type and monthly volume threshold, replacing any tier already defined for that pair. per_share takes up to 4 decimal places, percentage is a fraction of the notional between 0 and 1 with up to 6, and flat is in cents. Returns 201 when the tier is new and 200 when it replaced one. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param schedule body FeeScheduleRequest true "Fee tier"
+// @Success 200 {object} models.FeeSchedule
+// @Success 201 {object} models.FeeSchedule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/fees [post]
+func SetFeeSchedule(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input FeeScheduleRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	if input.Liquidity != engine.Maker && input.Liquidity != engine.Taker {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Liquidity must be either MAKER or TAKER"})
+		return
+	}
+	if input.MinMonthlyVolume < 0 || input.PerShare.IsNegative() || input.Percentage.IsNegative() || input.Flat.IsNegative() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Fee components and volume threshold must not be negative"})
+		return
+	}
+	if !input.PerShare.Equal(input.PerShare.Round(perSharePlaces, decimal.Down)) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "per_share must have at most 4 decimal places"})
+		return
+	}
+	if input.Percentage.GreaterThan(decimal.New(1, 0)) || !input.Percentage.Equal(input.Percentage.Round(percentagePlaces, decimal.Down)) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "percentage must be between 0 and 1 with at most 6 decimal places"})
+		return
+	}
+	if !input.Flat.Equal(input.Flat.Round(decimal.Cents, decimal.Down)) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "flat must be a whole number of cents"})
+		return
+	}
+
+	schedule := models.FeeSchedule{
+		Liquidity:        input.Liquidity,
+		MinMonthlyVolume: input.MinMonthlyVolume,
+		PerShare:         input.PerShare,
+		Percentage:       input.Percentage,
+		Flat:             input.Flat,
+	}
+
+	query := `
+		INSERT INTO fee_schedules (liquidity, min_monthly_volume, per_share, percentage, flat)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (liquidity, min_monthly_volume) DO UPDATE SET
+			per_share = EXCLUDED.per_share,
+			percentage = EXCLUDED.percentage,
+			flat = EXCLUDED.flat
+		RETURNING id, xmax = 0`
+	var created bool
+	err := db.QueryRow(query, schedule.Liquidity, schedule.MinMonthlyVolume, schedule.PerShare, schedule.Percentage, schedule.Flat).Scan(&schedule.ID, &created)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to save fee schedule"})
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusOK, schedule)
+		return
+	}
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// DeleteFeeSchedule godoc
+// @Summary Delete a fee tier
+// @Description Removes a fee tier. Fills that no longer match any tier are charged no commission. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Fee tier ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/fees/{id} [delete]
+func DeleteFeeSchedule(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid fee tier ID"})
+		return
+	}
+
+	result, err := db.Exec(`DELETE FROM fee_schedules WHERE id = $1`, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete fee schedule"})
+		return
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Fee tier not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Fee tier deleted successfully"})
+}
+
+// GetFeeRevenue godoc
+// @Summary Get exchange fee revenue
+// @Description Retrieves the balance of the exchange account credited with every commission charged. Commissions are charged in the currency of the trade: balance is the revenue in the base currency (USD), and balances lists the revenue in every currency, the base currency first.
+// @Tags Fee
+// @Accept json
+// @Produce json
+// @Success 200 {object} FeeRevenueResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/fees/revenue [get]
+func GetFeeRevenue(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	balances, err := ledger.AccountBalances(db, ledger.FeeRevenueAccount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve fee revenue"})
+		return
+	}
+
+	response := FeeRevenueResponse{Account: ledger.FeeRevenueAccount, Balances: balances}
+	for _, cash := range balances {
+		if cash.Currency == fx.Base {
+			response.Balance = cash.Balance
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
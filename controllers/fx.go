@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/utils/decimal"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FXRateRequest struct {
+	Rate decimal.Decimal `json:"rate" example:"1.0825"`
+}
+
+// GetFXRates godoc
+// @Summary List exchange rates
+// @Description Retrieves the configured exchange rates. Each rate is the price of one unit of the currency in the base currency (USD), and is used to value cash and positions held in that currency and to convert cash when buying stocks listed in it.
+// @Tags FX
+// @Accept json
+// @Produce json
+// @Success 200 {array} fx.Rate
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/fx-rates [get]
+func GetFXRates(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	rates, err := fx.List(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// SetFXRate godoc
+// @Summary Set an exchange rate
+// @Description Creates or replaces the rate of a currency, as the price of one unit of it in the base currency (USD). The base currency has no configurable rate. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param currency path string true "Currency code"
+// @Param rate body FXRateRequest true "Exchange rate"
+// @Success 200 {object} fx.Rate
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/fx-rates/{currency} [put]
+func SetFXRate(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input FXRateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	rate, err := fx.Set(db, strings.ToUpper(c.Param("currency")), input.Rate)
+	switch {
+	case errors.Is(err, fx.ErrInvalidCurrency):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Currency must be a three-letter ISO 4217 code other than the base currency"})
+		return
+	case errors.Is(err, fx.ErrInvalidRate):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Exchange rate must be positive"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to save exchange rate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
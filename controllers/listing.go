@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockUpdateRequest struct {
+	engine.StockUpdate
+	Reason string `json:"reason" example:"Relisted at auction price"`
+}
+
+// ReplaceStock godoc
+// @Summary Replace the settings of a stock
+// @Description Sets the last price and the initial and maintenance margin rates of the ticker; all three are required. The new price must lie inside the stock's static band and the dynamic band around the last price; it is added to the price history, does not move the reference price, and while the stock is tradable triggers stop orders or trips the circuit breaker like a trade. The change, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param ticker path string true "Stock Ticker"
+// @Param stock body StockUpdateRequest true "Stock settings"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/stocks/{ticker} [put]
+func ReplaceStock(c *gin.Context) {
+	var input StockUpdateRequest
+	if err := c.ShouldBindJSON(&input); err != nil || input.Price == nil || input.InitialMargin == nil || input.MaintenanceMargin == nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input. Ensure 'price', 'initial_margin' and 'maintenance_margin' are provided."})
+		return
+	}
+	updateStock(c, input)
+}
+
+// UpdateStock godoc
+// @Summary Update the settings of a stock
+// @Description Changes any of the last price and the initial and maintenance margin rates of the ticker; settings left out are kept. The new price must lie inside the stock's static band and the dynamic band around the last price; it is added to the price history, does not move the reference price, and while the stock is tradable triggers stop orders or trips the circuit breaker like a trade. The change, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param ticker path string true "Stock Ticker"
+// @Param stock body StockUpdateRequest true "Stock settings"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/stocks/{ticker} [patch]
+func UpdateStock(c *gin.Context) {
+	var input StockUpdateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+	updateStock(c, input)
+}
+
+func updateStock(c *gin.Context, input StockUpdateRequest) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var change *engine.StockChange
+	err := engine.RunInTx(db, func(tx *sql.Tx) error {
+		var err error
+		change, err = engine.UpdateStock(tx, c.Param("ticker"), input.StockUpdate, input.Reason, c.GetString("username"))
+		return err
+	})
+	if err != nil {
+		stockError(c, err, "Failed to update stock")
+		return
+	}
+
+	if change == nil {
+		c.JSON(http.StatusOK, SuccessResponse{Message: "Stock is unchanged."})
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Stock updated successfully."})
+}
+
+// DelistStock godoc
+// @Summary Delist a stock
+// @Description Stops trading in the ticker for good. Open orders in it are cancelled and scheduled corporate actions are called off; holders keep their positions and dividends already recorded are still paid. The delisting, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param ticker path string true "Stock Ticker"
+// @Param reason query string false "Reason for delisting"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/stocks/{ticker} [delete]
+func DelistStock(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	err := engine.RunInTx(db, func(tx *sql.Tx) error {
+		_, err := engine.DelistStock(tx, c.Param("ticker"), c.Query("reason"), c.GetString("username"))
+		return err
+	})
+	if err != nil {
+		stockError(c, err, "Failed to delist stock")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Stock delisted successfully."})
+}
+
+// GetStockHistory godoc
+// @Summary Get the change history of a stock
+// @Description Returns who listed, changed and delisted the ticker and when, oldest first. Each entry lists the old and new value of every setting that changed.
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param ticker path string true "Stock Ticker"
+// @Success 200 {array} engine.StockChange
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/stocks/{ticker}/history [get]
+func GetStockHistory(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	ticker := c.Param("ticker")
+
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM stocks WHERE ticker = $1)`, ticker).Scan(&exists); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve stock history"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Stock not found."})
+		return
+	}
+
+	history, err := engine.StockHistory(db, ticker)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve stock history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func stockError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, engine.ErrStockNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Stock not found."})
+	case errors.Is(err, engine.ErrStockDelisted):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Stock is delisted"})
+	case errors.Is(err, engine.ErrInvalidStock):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Price must be a positive amount in whole cents and margin rates must satisfy 0 < maintenance_margin <= initial_margin <= 1"})
+	case errors.Is(err, engine.ErrPriceOutOfBand):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Price is outside the stock's price bands"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type MarketStatusResponse struct {
+	Phase     string        `json:"phase" example:"OPEN"`
+	Timezone  string        `json:"timezone" example:"America/New_York"`
+	NextOpen  time.Time     `json:"next_open"`
+	NextClose time.Time     `json:"next_close"`
+	Halts     []engine.Halt `json:"halts"`
+}
+
+type CalendarRequest struct {
+	Timezone       string  `json:"timezone" example:"America/New_York"`
+	PreOpen        string  `json:"pre_open" example:"04:00"`
+	Open           string  `json:"open" example:"09:30"`
+	Close          string  `json:"close" example:"16:00"`
+	TradingDays    []int64 `json:"trading_days"`
+	SettlementDays *int    `json:"settlement_days" example:"1"`
+}
+
+type HaltRequest struct {
+	Ticker string `json:"ticker" example:"AAPL"`
+	Reason string `json:"reason" example:"Pending news"`
+}
+
+type ResumeRequest struct {
+	Ticker string `json:"ticker" example:"AAPL"`
+}
+
+// GetMarketStatus godoc
+// @Summary Get market status
+// @Description Returns the current trading phase (PRE_OPEN, OPEN or CLOSED), the next session open and close, and every trading halt in force. Orders are only accepted while the phase is OPEN.
+// @Tags Market
+// @Accept json
+// @Produce json
+// @Success 200 {object} MarketStatusResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/market/status [get]
+func GetMarketStatus(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	calendar, err := engine.LoadCalendar(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to load market calendar"})
+		return
+	}
+
+	halts, err := engine.ActiveHalts(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve trading halts"})
+		return
+	}
+
+	now := time.Now()
+	c.JSON(http.StatusOK, MarketStatusResponse{
+		Phase:     calendar.Phase(now),
+		Timezone:  calendar.Timezone,
+		NextOpen:  calendar.NextOpen(now),
+		NextClose: calendar.NextClose(now),
+		Halts:     halts,
+	})
+}
+
+// GetCalendar godoc
+// @Summary Get market calendar
+// @Description Returns the session times, trading days (0 = Sunday), settlement cycle and holidays of the exchange.
+// @Tags Market
+// @Accept json
+// @Produce json
+// @Success 200 {object} engine.Calendar
+// @Failure 500 {object} ErrorResponse
+// @Router /api/market/calendar [get]
+func GetCalendar(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	calendar, err := engine.LoadCalendar(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to load market calendar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
+
+// UpdateCalendar godoc
+// @Summary Update market calendar
+// @Description Replaces the exchange timezone, session times (HH:MM) and trading days (0 = Sunday), and optionally the settlement cycle: trades settle settlement_days trading days (0 to 5) after the trade date. The cycle applies to trades made from then on and is left unchanged when settlement_days is omitted. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param calendar body CalendarRequest true "Calendar"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/calendar [put]
+func UpdateCalendar(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input CalendarRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	current, err := engine.LoadCalendar(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to load market calendar"})
+		return
+	}
+
+	calendar := engine.Calendar{
+		Timezone:       input.Timezone,
+		PreOpen:        input.PreOpen,
+		Open:           input.Open,
+		Close:          input.Close,
+		TradingDays:    input.TradingDays,
+		SettlementDays: current.SettlementDays,
+	}
+	if input.SettlementDays != nil {
+		calendar.SettlementDays = *input.SettlementDays
+	}
+	if err := calendar.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid calendar: " + err.Error()})
+		return
+	}
+
+	query := `
+		UPDATE market_calendar
+		SET timezone = $1, pre_open_time = $2, open_time = $3, close_time = $4, trading_days = $5, settlement_days = $6
+		WHERE id = 1`
+	_, err = db.Exec(query, calendar.Timezone, calendar.PreOpen, calendar.Open, calendar.Close, pq.Array(calendar.TradingDays),
+		calendar.SettlementDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update market calendar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Market calendar updated successfully"})
+}
+
+// AddHoliday godoc
+// @Summary Add a market holiday
+// @Description Closes the exchange for a whole date (YYYY-MM-DD). Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param holiday body engine.Holiday true "Holiday"
+// @Success 201 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/holidays [post]
+func AddHoliday(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input engine.Holiday
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	if _, err := time.Parse("2006-01-02", input.Date); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Date must be in YYYY-MM-DD format"})
+		return
+	}
+
+	query := `
+		INSERT INTO market_holidays (date, description) VALUES ($1, $2)
+		ON CONFLICT (date) DO UPDATE SET description = EXCLUDED.description`
+	if _, err := db.Exec(query, input.Date, input.Description); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to add holiday"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{Message: "Holiday added successfully"})
+}
+
+// DeleteHoliday godoc
+// @Summary Remove a market holiday
+// @Description Reopens a date previously declared a holiday. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param date path string true "Holiday date in YYYY-MM-DD format" format(date)
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/holidays/{date} [delete]
+func DeleteHoliday(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	if _, err := time.Parse("2006-01-02", c.Param("date")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Date must be in YYYY-MM-DD format"})
+		return
+	}
+
+	result, err := db.Exec(`DELETE FROM market_holidays WHERE date = $1`, c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to remove holiday"})
+		return
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Holiday not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Holiday removed successfully"})
+}
+
+// HaltTrading godoc
+// @Summary Halt trading
+// @Description Suspends order entry in a single ticker, or on the whole exchange when no ticker is given. Resting orders stay on the book and can still be cancelled. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param halt body HaltRequest true "Halt"
+// @Success 201 {object} engine.Halt
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/halts [post]
+func HaltTrading(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input HaltRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	halt, err := engine.HaltTrading(db, input.Ticker, input.Reason, c.GetString("username"), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to halt trading"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, halt)
+}
+
+// ResumeTrading godoc
+// @Summary Resume trading
+// @Description Lifts the halts on a single ticker, or the exchange-wide halts when no ticker is given. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param resume body ResumeRequest true "Ticker to resume"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/resume [post]
+func ResumeTrading(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input ResumeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	resumed, err := engine.ResumeTrading(db, input.Ticker)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to resume trading"})
+		return
+	}
+	if resumed == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No active halt found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Trading resumed successfully"})
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AmendOrderRequest struct {
+	LimitPrice *decimal.Decimal `json:"limit_price" example:"151.00"`
+	StopPrice  *decimal.Decimal `json:"stop_price"`
+	Quantity   *int             `json:"quantity" example:"20"`
+}
+
+// GetOrders godoc
+// @Summary List orders
+// @Description Retrieves the caller's orders, newest first, optionally filtered by ticker and status.
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param ticker query string false "Stock ticker"
+// @Param status query string false "Order status" Enums(NEW, PARTIALLY_FILLED, FILLED, CANCELLED, REJECTED, EXPIRED)
+// @Success 200 {array} models.Order
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/orders [get]
+func GetOrders(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	filter := engine.OrderFilter{
+		Ticker: c.Query("ticker"),
+		Status: c.Query("status"),
+	}
+
+	orders, err := engine.ListOrders(db, c.GetString("username"), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetOrder godoc
+// @Summary Get order by ID
+// @Description Retrieves a single order of the caller and its current state. Orders of other users are not found.
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/orders/{id} [get]
+func GetOrder(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	order, err := engine.FindOrder(db, c.GetString("username"), id)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelOrder godoc
+// @Summary Cancel an open order
+// @Description Takes a NEW or PARTIALLY_FILLED order of the caller off the book. Shares already filled are unaffected.
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/orders/{id} [delete]
+func CancelOrder(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	var order *models.Order
+	err = engine.RunInTx(db, func(tx *sql.Tx) error {
+		var err error
+		order, err = engine.Cancel(tx, c.GetString("username"), id)
+		return err
+	})
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// AmendOrder godoc
+// @Summary Amend an open order
+// @Description Replaces the limit price, stop price or total quantity of an open order of the caller. Reducing the quantity keeps the order's queue priority; changing a price or increasing the quantity loses it. The amended order is matched again if it now crosses the book.
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param amendment body controllers.AmendOrderRequest true "Fields to replace"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/orders/{id} [patch]
+func AmendOrder(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	var input AmendOrderRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	amendment := engine.Amendment{
+		Price:     input.LimitPrice,
+		StopPrice: input.StopPrice,
+		Quantity:  input.Quantity,
+	}
+
+	var order *models.Order
+	var fills []engine.Fill
+	err = engine.RunInTx(db, func(tx *sql.Tx) error {
+		var err error
+		order, fills, err = engine.Amend(tx, c.GetString("username"), id, amendment)
+		return err
+	})
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, OrderResponse{
+		Message: "Order amended",
+		Order:   *order,
+		Fills:   fills,
+	})
+}
+
+// orderError writes the HTTP response for an error raised while working
+// with an existing order.
+func orderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, engine.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+	case errors.Is(err, engine.ErrOrderClosed):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Order is no longer open"})
+	default:
+		engineError(c, err)
+	}
+}
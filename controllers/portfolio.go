@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/fx"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CostBasisRequest struct {
+	Method string `json:"method" example:"FIFO" enums:"FIFO,LIFO,AVERAGE"`
+}
+
+// GetPortfolio godoc
+// @Summary Get the portfolio summary of a user
+// @Description Values every open position at the stock's last trade price and reports its cost basis, unrealized P&L and the P&L realized in the ticker so far, in the ticker's currency. Cost basis follows the account's cost-basis method: the open tax lots under FIFO and LIFO, the average cost under AVERAGE. Totals are in the base currency (USD): market value and cost basis at current exchange rates, realized P&L and fees at the rates of the trades. Realized P&L is before fees; equity is cash plus market value. Only the login linked to the account and admins may view it.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Success 200 {object} engine.Portfolio
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/portfolio [get]
+func GetPortfolio(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	userID, ok := lookupUserID(c, db)
+	if !ok {
+		return
+	}
+
+	portfolio, err := engine.LoadPortfolio(db, userID)
+	if errors.Is(err, fx.ErrNoRate) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "No exchange rate is configured for a held stock's currency"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to value portfolio"})
+		return
+	}
+
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// SetCostBasisMethod godoc
+// @Summary Set the cost-basis method of a user
+// @Description Chooses which shares the user's closing trades dispose of when realizing P&L: FIFO closes the oldest tax lots first, LIFO the newest, and AVERAGE closes at the position's average cost. The method applies to trades from now on; P&L already realized is not restated. Only the login linked to the account and admins may change it.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Param method body controllers.CostBasisRequest true "Cost-basis method"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/cost-basis-method [put]
+func SetCostBasisMethod(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input CostBasisRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	userID, ok := lookupUserID(c, db)
+	if !ok {
+		return
+	}
+
+	err := engine.SetCostBasisMethod(db, userID, strings.ToUpper(strings.TrimSpace(input.Method)))
+	switch {
+	case errors.Is(err, engine.ErrInvalidCostBasis):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Method must be FIFO, LIFO or AVERAGE"})
+		return
+	case errors.Is(err, engine.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update cost-basis method"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Cost-basis method updated"})
+}
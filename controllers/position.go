@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPositions godoc
+// @Summary Get positions for a user
+// @Description Retrieves the quantity and average cost held by a user in every ticker. settled_quantity excludes shares bought or sold in trades that have not settled yet. Only the login linked to the account and admins may view them.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Success 200 {array} models.Position
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/positions [get]
+func GetPositions(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	userID, ok := lookupUserID(c, db)
+	if !ok {
+		return
+	}
+
+	positions, err := engine.Positions(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve positions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, positions)
+}
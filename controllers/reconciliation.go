@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/reconcile"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reconcile godoc
+// @Summary Reconcile balances and holdings
+// @Description Recomputes every user's cash from the ledger, the cash their trades moved from the transaction history, and their positions from their trades, share issuances and split adjustments, and reports each difference as a break: CASH_BALANCE, TRADE_CASH, POSITION or TAX_LOTS. Cash breaks are in the break's currency, the others in shares. Nothing is changed. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} reconcile.Report
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/reconciliation [get]
+func Reconcile(c *gin.Context) {
+	reconcileWith(c, false)
+}
+
+// CorrectBreaks godoc
+// @Summary Reconcile and correct breaks
+// @Description Runs the same reconciliation and corrects the breaks it finds. The ledger and the transaction history are the books of record: cached cash balances, positions and tax lots are rewritten to agree with them, and trade cash is corrected by a TRADE_ADJUSTMENT journal entry against the exchange's RECONCILIATION account. Positions are only corrected to whole shares; breaks left uncorrected are reported with corrected false. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} reconcile.Report
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/reconciliation/corrections [post]
+func CorrectBreaks(c *gin.Context) {
+	reconcileWith(c, true)
+}
+
+func reconcileWith(c *gin.Context, correct bool) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	report, err := reconcile.Run(db, correct)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to reconcile accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
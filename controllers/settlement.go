@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SettleTradesResponse struct {
+	Settled int `json:"settled"`
+}
+
+// GetSettlements godoc
+// @Summary Get the settlement obligations of a user
+// @Description Lists what each side of the user's trades delivers and receives on its settlement date, latest trade first: quantity shares and amount cash in the trade's currency, commission included, each positive when received. Trades settle a number of trading days after the trade date set by the market calendar. Until then, the cash received waits in the user's unsettled account, where cash accounts cannot spend it and no account can withdraw it, and the shares received or delivered are left out of the position's settled_quantity; settlement moves both to the settled side. status filters by PENDING or SETTLED. Only the login linked to the account and admins may view them.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Param status query string false "Settlement status" Enums(PENDING, SETTLED)
+// @Success 200 {array} engine.Settlement
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/settlements [get]
+func GetSettlements(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	status := strings.ToUpper(c.Query("status"))
+	if status != "" && status != engine.SettlementPending && status != engine.SettlementSettled {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Status must be PENDING or SETTLED"})
+		return
+	}
+
+	userID, ok := lookupUserID(c, db)
+	if !ok {
+		return
+	}
+
+	settlements, err := engine.Settlements(db, userID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve settlements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settlements)
+}
+
+// SettleTrades godoc
+// @Summary Settle due trades now
+// @Description Settles every pending obligation whose settlement date has been reached, without waiting for the background settlement run. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} SettleTradesResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/settlements/process [post]
+func SettleTrades(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	settled, err := engine.SettleTrades(db, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to settle trades"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SettleTradesResponse{Settled: settled})
+}
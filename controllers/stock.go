@@ -4,30 +4,54 @@ import (
 	"database/sql"
 	"net/http"
 	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/utils/decimal"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Stock struct {
-	ID     int     `json:"id"`
-	Ticker string  `json:"ticker" binding:"required"`
-	Price  float64 `json:"price" binding:"required"`
+	ID                int             `json:"id"`
+	Ticker            string          `json:"ticker" binding:"required"`
+	Price             decimal.Decimal `json:"price" binding:"required"`
+	Currency          string          `json:"currency"`
+	InitialMargin     decimal.Decimal `json:"initial_margin"`
+	MaintenanceMargin decimal.Decimal `json:"maintenance_margin"`
+	DelistedAt        *time.Time      `json:"delisted_at,omitempty"`
+}
+
+type MarginRequest struct {
+	InitialMargin     decimal.Decimal `json:"initial_margin" example:"0.5"`
+	MaintenanceMargin decimal.Decimal `json:"maintenance_margin" example:"0.25"`
+	Reason            string          `json:"reason" example:"Volatility review"`
+}
+
+type PriceBandsRequest struct {
+	engine.Bands
+	Reason string `json:"reason" example:"Wider bands for earnings day"`
 }
 
 type CreateStockRequest struct {
-	Ticker string  `json:"ticker" example:"AAPL"`
-	Price  float64 `json:"price" example:"150.25"`
+	Ticker        string          `json:"ticker" example:"AAPL"`
+	Price         decimal.Decimal `json:"price" example:"150.25"`
+	Currency      string          `json:"currency" example:"USD"`
+	InitialHolder string          `json:"initial_holder" example:"abdullah"`
+	InitialShares int             `json:"initial_shares" example:"1000"`
 }
 
 // CreateStock godoc
 // @Summary Create a new stock entry
-// @Description Saves new stock data into the database.
-// @Tags Stock
+// @Description Saves new stock data into the database. The stock trades in currency, which defaults to the base currency (USD). When initial_holder and initial_shares are given, the shares are issued to that user at the listing price. Requires an admin account.
+// @Tags Admin
 // @Accept json
 // @Produce json
 // @Param stock body CreateStockRequest true "Stock data"
 // @Success 201 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /api/stocks [post]
@@ -41,19 +65,80 @@ func CreateStock(c *gin.Context) {
 		return
 	}
 
-	query := `INSERT INTO stocks (ticker, price) VALUES ($1, $2)`
-	_, err := db.Exec(query, stock.Ticker, stock.Price)
+	if !stock.Price.IsPositive() || !stock.Price.Equal(stock.Price.Round(decimal.Cents, decimal.Down)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input. 'price' must be a positive amount in whole cents."})
+		return
+	}
+
+	if stock.Currency == "" {
+		stock.Currency = fx.Base
+	}
+	if !fx.ValidCurrency(stock.Currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input. 'currency' must be a three-letter ISO 4217 code."})
+		return
+	}
+
+	if stock.InitialShares < 0 || (stock.InitialShares > 0 && stock.InitialHolder == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input. 'initial_shares' must be positive and issued to an 'initial_holder'."})
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stock in the database."})
+		return
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO stocks (ticker, price, reference_price, currency) VALUES ($1, $2, $2, $3)`
+	_, err = tx.Exec(query, stock.Ticker, stock.Price, stock.Currency)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stock in the database."})
 		return
 	}
 
+	if err := engine.ListStock(tx, stock.Ticker, stock.Price); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stock in the database."})
+		return
+	}
+
+	changes := map[string]engine.FieldChange{
+		"price":    {New: stock.Price.String()},
+		"currency": {New: stock.Currency},
+	}
+	if _, err := engine.RecordStockChange(tx, stock.Ticker, engine.StockCreated, changes, "", c.GetString("username")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stock in the database."})
+		return
+	}
+
+	if stock.InitialShares > 0 {
+		var holderID int
+		err := tx.QueryRow(`SELECT id FROM users WHERE username = $1`, stock.InitialHolder).Scan(&holderID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Initial holder not found."})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stock in the database."})
+			return
+		}
+
+		if err := engine.IssueShares(tx, holderID, stock.Ticker, stock.InitialShares, stock.Price); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue initial shares."})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stock in the database."})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"message": "Stock created successfully."})
 }
 
 // GetAllStocks godoc
 // @Summary Retrieve all stocks
-// @Description Retrieves all stock entries from the database.
+// @Description Retrieves all listed stocks; delisted stocks are left out.
 // @Tags Stock
 // @Accept json
 // @Produce json
@@ -65,7 +150,7 @@ func GetAllStocks(c *gin.Context) {
 	db := config.ConnectDB()
 	defer db.Close()
 
-	rows, err := db.Query(`SELECT id, ticker, price FROM stocks`)
+	rows, err := db.Query(`SELECT id, ticker, price, currency, initial_margin, maintenance_margin FROM stocks WHERE delisted_at IS NULL`)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error: "Failed to retrieve stocks from the database.",
@@ -77,7 +162,7 @@ func GetAllStocks(c *gin.Context) {
 	var stocks []Stock
 	for rows.Next() {
 		var stock Stock
-		err := rows.Scan(&stock.ID, &stock.Ticker, &stock.Price)
+		err := rows.Scan(&stock.ID, &stock.Ticker, &stock.Price, &stock.Currency, &stock.InitialMargin, &stock.MaintenanceMargin)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
 				Error: "Failed to parse stock data.",
@@ -93,7 +178,7 @@ func GetAllStocks(c *gin.Context) {
 // @Security BearerAuth
 // GetStockByTicker godoc
 // @Summary Retrieve stock by ticker
-// @Description Retrieves stock details based on the provided ticker symbol.
+// @Description Retrieves stock details based on the provided ticker symbol. delisted_at is set once the stock has been delisted.
 // @Tags Stock
 // @Accept json
 // @Produce json
@@ -110,8 +195,8 @@ func GetStockByTicker(c *gin.Context) {
 	ticker := c.Param("ticker")
 
 	var stock Stock
-	query := `SELECT id, ticker, price FROM stocks WHERE ticker = $1`
-	err := db.QueryRow(query, ticker).Scan(&stock.ID, &stock.Ticker, &stock.Price)
+	query := `SELECT id, ticker, price, currency, initial_margin, maintenance_margin, delisted_at FROM stocks WHERE ticker = $1`
+	err := db.QueryRow(query, ticker).Scan(&stock.ID, &stock.Ticker, &stock.Price, &stock.Currency, &stock.InitialMargin, &stock.MaintenanceMargin, &stock.DelistedAt)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error: "Stock not found.",
@@ -126,3 +211,111 @@ func GetStockByTicker(c *gin.Context) {
 
 	c.JSON(http.StatusOK, stock)
 }
+
+// SetStockMargin godoc
+// @Summary Configure margin rates for a stock
+// @Description Sets the initial and maintenance margin rates applied to positions in the ticker. Rates are fractions of position value; the maintenance rate may not exceed the initial rate. The change, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param ticker path string true "Stock Ticker"
+// @Param margin body MarginRequest true "Margin rates"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/stocks/{ticker}/margin [put]
+func SetStockMargin(c *gin.Context) {
+	var input MarginRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	updateStock(c, StockUpdateRequest{
+		StockUpdate: engine.StockUpdate{
+			InitialMargin:     &input.InitialMargin,
+			MaintenanceMargin: &input.MaintenanceMargin,
+		},
+		Reason: input.Reason,
+	})
+}
+
+// GetPriceBands godoc
+// @Summary Get price bands of a stock
+// @Description Returns the reference price, static and dynamic price bands and circuit breaker settings of the ticker. Bands and the threshold are fractions of a price; null means the check is disabled.
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param ticker path string true "Stock Ticker"
+// @Success 200 {object} engine.Bands
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/stocks/{ticker}/price-bands [get]
+func GetPriceBands(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	bands, err := engine.LoadBands(db, c.Param("ticker"))
+	if err == engine.ErrStockNotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Stock not found."})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve price bands"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bands)
+}
+
+// SetPriceBands godoc
+// @Summary Configure price bands of a stock
+// @Description Replaces the reference price, price bands and circuit breaker settings of the ticker. Limit orders priced outside the static band around the reference price are rejected, and no trade may print outside the static band or the dynamic band around the last trade. When trades within the breaker window move more than the threshold, trading in the ticker halts for the configured number of minutes. Send null for a band or threshold to disable it. The change, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param ticker path string true "Stock Ticker"
+// @Param bands body PriceBandsRequest true "Price bands"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/stocks/{ticker}/price-bands [put]
+func SetPriceBands(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input PriceBandsRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid price bands: " + err.Error()})
+		return
+	}
+
+	var change *engine.StockChange
+	err := engine.RunInTx(db, func(tx *sql.Tx) error {
+		var err error
+		change, err = engine.SetBands(tx, c.Param("ticker"), input.Bands, input.Reason, c.GetString("username"))
+		return err
+	})
+	if err != nil {
+		stockError(c, err, "Failed to update price bands")
+		return
+	}
+
+	if change == nil {
+		c.JSON(http.StatusOK, SuccessResponse{Message: "Price bands are unchanged."})
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse{Message: "Price bands updated successfully."})
+}
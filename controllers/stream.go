@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"stock_exchange_Golang_project/stream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+// StreamMarketData godoc
+// @Summary Stream live market data
+// @Description Upgrades the connection to a WebSocket that streams quotes, trade prints and order book changes. Send {"action": "subscribe", "tickers": ["AAPL"]} to subscribe and "unsubscribe" to stop. Each subscribed ticker starts with a snapshot of its quote and book, followed by tick, trade and book messages; book messages carry the new quantity of each changed price level, zero removing it. Every message carries a seq numbered consecutively from 1 on the connection, a heartbeat message is sent every 15 seconds, and peers are pinged to detect dead connections. Clients that fall 256 messages behind are disconnected with close code 1013 and should reconnect and resubscribe.
+// @Tags Market
+// @Success 101 {object} stream.Message
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/market/stream [get]
+func StreamMarketData(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already answered with an HTTP error.
+		return
+	}
+	stream.Serve(conn, c.GetString("username"))
+}
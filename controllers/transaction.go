@@ -3,28 +3,49 @@ package controllers
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Transaction struct {
-	ID                int     `json:"id"`
-	UserID            int     `json:"user_id"`
-	Ticker            string  `json:"ticker"`
-	TransactionType   string  `json:"transaction_type"`
-	TransactionVolume int     `json:"transaction_volume"`
-	TransactionPrice  float64 `json:"transaction_price"`
-	Timestamp         string  `json:"timestamp"`
+	ID                int             `json:"id"`
+	UserID            int             `json:"user_id"`
+	Ticker            string          `json:"ticker"`
+	TransactionType   string          `json:"transaction_type"`
+	TransactionVolume int             `json:"transaction_volume"`
+	TransactionPrice  decimal.Decimal `json:"transaction_price"`
+	Fee               decimal.Decimal `json:"fee"`
+	Liquidity         string          `json:"liquidity"`
+	Currency          string          `json:"currency"`
+	FXRate            decimal.Decimal `json:"fx_rate"`
+	SplitFactor       decimal.Decimal `json:"split_factor"`
+	RealizedPnL       decimal.Decimal `json:"realized_pnl"`
+	Timestamp         string          `json:"timestamp"`
 }
 
 type TransactionRequest struct {
-	Username          string `json:"username" example:"abdullah"`
-	Ticker            string `json:"ticker" example:"tia"`
-	TransactionType   string `json:"transaction_type" example:"BUY"`
-	TransactionVolume int    `json:"transaction_volume" example:"10"`
+	Ticker            string          `json:"ticker" example:"tia"`
+	TransactionType   string          `json:"transaction_type" example:"BUY"`
+	TransactionVolume int             `json:"transaction_volume" example:"10"`
+	OrderType         string          `json:"order_type" example:"LIMIT" enums:"MARKET,LIMIT,STOP,STOP_LIMIT"`
+	LimitPrice        decimal.Decimal `json:"limit_price" example:"150.25"`
+	StopPrice         decimal.Decimal `json:"stop_price" example:"0"`
+	TimeInForce       string          `json:"time_in_force" example:"DAY" enums:"DAY,GTC,IOC,FOK,GTD"`
+	ExpiresAt         *time.Time      `json:"expires_at" example:"2024-12-31T16:00:00Z"`
+}
+
+type OrderResponse struct {
+	Message string        `json:"message"`
+	Order   models.Order  `json:"order"`
+	Fills   []engine.Fill `json:"fills"`
 }
 
 type SuccessResponse struct {
@@ -37,15 +58,18 @@ type ErrorResponse struct {
 }
 
 // CreateTransaction godoc
-// @Summary Create a new transaction
-// @Description Creates a new transaction record in the system.
+// @Summary Submit a new order
+// @Description Places a BUY or SELL order for the trading account linked to the caller's login on the ticker's order book and matches it against resting orders by price-time priority. order_type is one of MARKET, LIMIT (requires limit_price), STOP (requires stop_price) or STOP_LIMIT (requires both); when omitted it is inferred from the prices given. Market orders never rest, and stop orders wait until the last trade price reaches stop_price. time_in_force is one of DAY (default, expires at session close), GTC, IOC (remainder cancelled), FOK (rejected unless filled in full) or GTD (expires at expires_at). Orders are only accepted while the market is open and the ticker is not halted. Margin accounts in a margin call, whose equity is below their maintenance requirement, may only place orders that reduce their existing positions until the call is met. Each fill is recorded as a transaction for both parties. Stocks listed in another currency trade in that currency: a buyer short of cash in it has the difference converted from their base currency cash at the configured exchange rate, and the rate is recorded on the transaction. Send an Idempotency-Key header to make retries safe: a repeated key replays the first response instead of placing the order again.
 // @Tags Transaction
 // @Accept json
 // @Produce json
 // @Param transaction body controllers.TransactionRequest true "Transaction data"
-// @Success 201 {object} SuccessResponse
+// @Param Idempotency-Key header string false "Unique key that makes the request safe to retry"
+// @Success 201 {object} OrderResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /api/transactions [post]
@@ -60,64 +84,121 @@ func CreateTransaction(c *gin.Context) {
 		return
 	}
 
-	if input.TransactionType != "BUY" && input.TransactionType != "SELL" {
+	if input.TransactionType != engine.Buy && input.TransactionType != engine.Sell {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "TransactionType must be either BUY or SELL"})
 		return
 	}
 
-	var userBalance float64
-	err := db.QueryRow(`SELECT balance FROM users WHERE username = $1`, input.Username).Scan(&userBalance)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
-		return
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Error fetching user balance"})
+	if input.TransactionVolume <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "TransactionVolume must be positive"})
 		return
 	}
 
-	var stockPrice float64
-	err = db.QueryRow(`SELECT price FROM stocks WHERE ticker = $1`, input.Ticker).Scan(&stockPrice)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Stock not found"})
-		return
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Error fetching stock price"})
-		return
+	if input.OrderType == "" {
+		input.OrderType = defaultOrderType(input)
 	}
 
-	transactionPrice := stockPrice * float64(input.TransactionVolume)
-	if input.TransactionType == "BUY" && userBalance < transactionPrice {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Insufficient balance"})
-		return
+	if input.TimeInForce == "" {
+		input.TimeInForce = engine.Day
 	}
 
-	var balanceQuery string
-	if input.TransactionType == "BUY" {
-		balanceQuery = `UPDATE users SET balance = balance - $1 WHERE username = $2`
-	} else {
-		balanceQuery = `UPDATE users SET balance = balance + $1 WHERE username = $2`
-	}
-	_, err = db.Exec(balanceQuery, transactionPrice, input.Username)
+	var order models.Order
+	var fills []engine.Fill
+	err := engine.RunInTx(db, func(tx *sql.Tx) error {
+		order = models.Order{
+			Ticker:      input.Ticker,
+			Side:        input.TransactionType,
+			OrderType:   input.OrderType,
+			Price:       input.LimitPrice,
+			StopPrice:   input.StopPrice,
+			TimeInForce: input.TimeInForce,
+			ExpiresAt:   input.ExpiresAt,
+			Quantity:    input.TransactionVolume,
+		}
+		userID, err := engine.LinkedUser(tx, c.GetString("username"))
+		if err != nil {
+			return err
+		}
+		fills, err = engine.Submit(tx, userID, &order)
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update balance"})
+		engineError(c, err)
 		return
 	}
 
-	insertQuery := `
-        INSERT INTO transactions (user_id, ticker, transaction_type, transaction_volume, transaction_price, timestamp)
-        VALUES ((SELECT id FROM users WHERE username = $1), $2, $3, $4, $5, $6)`
-	_, err = db.Exec(insertQuery, input.Username, input.Ticker, input.TransactionType, input.TransactionVolume, transactionPrice, time.Now())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create transaction"})
-		return
+	c.JSON(http.StatusCreated, OrderResponse{
+		Message: orderMessage(order.Status),
+		Order:   order,
+		Fills:   fills,
+	})
+}
+
+// orderMessage describes the state an order was left in after submission.
+func orderMessage(status string) string {
+	switch status {
+	case engine.StatusFilled:
+		return "Order filled"
+	case engine.StatusPartiallyFilled:
+		return "Order partially filled"
+	case engine.StatusCancelled:
+		return "Order cancelled"
+	case engine.StatusRejected:
+		return "Order rejected"
+	default:
+		return "Order accepted"
 	}
+}
 
-	c.JSON(http.StatusCreated, SuccessResponse{Message: "Transaction completed successfully"})
+// defaultOrderType infers the order type of a request that does not name
+// one from the prices it carries.
+func defaultOrderType(input TransactionRequest) string {
+	switch {
+	case input.StopPrice.IsPositive() && input.LimitPrice.IsPositive():
+		return engine.StopLimit
+	case input.StopPrice.IsPositive():
+		return engine.Stop
+	case input.LimitPrice.IsPositive():
+		return engine.Limit
+	default:
+		return engine.Market
+	}
+}
+
+// engineError writes the HTTP response for an error returned by the
+// matching engine.
+func engineError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, engine.ErrInvalidOrder):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order: check order_type, limit_price, stop_price, time_in_force and expires_at"})
+	case errors.Is(err, engine.ErrPriceOutOfBand):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Limit price is outside the stock's price band"})
+	case errors.Is(err, engine.ErrMarketClosed):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Market is closed"})
+	case errors.Is(err, engine.ErrTradingHalted):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Trading is halted"})
+	case errors.Is(err, engine.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+	case errors.Is(err, engine.ErrStockNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Stock not found"})
+	case errors.Is(err, engine.ErrStockDelisted):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Stock is delisted"})
+	case errors.Is(err, engine.ErrInsufficientBalance):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Insufficient buying power"})
+	case errors.Is(err, engine.ErrInsufficientShares):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Insufficient shares"})
+	case errors.Is(err, engine.ErrMarginCall):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Account is in a margin call: only orders that reduce positions are accepted"})
+	case errors.Is(err, fx.ErrNoRate):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "No exchange rate is configured for the stock's currency"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to process order"})
+	}
 }
 
 // GetTransactions godoc
 // @Summary Get all Transactions for a user
-// @Description Retrieves a list of all transactions for a given user.
+// @Description Retrieves a list of all transactions for a given user. Trades are reported as executed; transaction_volume * split_factor restates the volume in shares after any later splits. realized_pnl is the profit or loss, before fees, that the trade realized by closing shares under the account's cost-basis method.
 // @Tags Transaction
 // @Accept json
 // @Produce json
@@ -133,7 +214,7 @@ func GetTransactions(c *gin.Context) {
 	username := c.Param("username")
 
 	query := `
-		SELECT t.id, t.ticker, t.transaction_type, t.transaction_volume, t.transaction_price, t.timestamp
+		SELECT t.id, t.ticker, t.transaction_type, t.transaction_volume, t.transaction_price, t.fee, COALESCE(t.liquidity, ''), t.currency, t.fx_rate, t.split_factor, t.realized_pnl, t.timestamp
 		FROM transactions t
 		INNER JOIN users u ON t.user_id = u.id
 		WHERE u.username = $1
@@ -149,7 +230,7 @@ func GetTransactions(c *gin.Context) {
 	var transactions []Transaction
 	for rows.Next() {
 		var transaction Transaction
-		err := rows.Scan(&transaction.ID, &transaction.Ticker, &transaction.TransactionType, &transaction.TransactionVolume, &transaction.TransactionPrice, &transaction.Timestamp)
+		err := rows.Scan(&transaction.ID, &transaction.Ticker, &transaction.TransactionType, &transaction.TransactionVolume, &transaction.TransactionPrice, &transaction.Fee, &transaction.Liquidity, &transaction.Currency, &transaction.FXRate, &transaction.SplitFactor, &transaction.RealizedPnL, &transaction.Timestamp)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing transactions"})
 			return
@@ -187,7 +268,7 @@ func GetTransactionsByDate(c *gin.Context) {
 	endTime := c.Param("end_time")
 
 	query := `
-		SELECT t.id, t.ticker, t.transaction_type, t.transaction_volume, t.transaction_price, t.timestamp
+		SELECT t.id, t.ticker, t.transaction_type, t.transaction_volume, t.transaction_price, t.fee, COALESCE(t.liquidity, ''), t.currency, t.fx_rate, t.split_factor, t.realized_pnl, t.timestamp
 		FROM transactions t
 		INNER JOIN users u ON t.user_id = u.id
 		WHERE u.username = $1 AND t.timestamp BETWEEN $2 AND $3
@@ -203,7 +284,7 @@ func GetTransactionsByDate(c *gin.Context) {
 	var transactions []Transaction
 	for rows.Next() {
 		var transaction Transaction
-		err := rows.Scan(&transaction.ID, &transaction.Ticker, &transaction.TransactionType, &transaction.TransactionVolume, &transaction.TransactionPrice, &transaction.Timestamp)
+		err := rows.Scan(&transaction.ID, &transaction.Ticker, &transaction.TransactionType, &transaction.TransactionVolume, &transaction.TransactionPrice, &transaction.Fee, &transaction.Liquidity, &transaction.Currency, &transaction.FXRate, &transaction.SplitFactor, &transaction.RealizedPnL, &transaction.Timestamp)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing transactions"})
 			return
@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/middleware"
+	"stock_exchange_Golang_project/payments"
+	"stock_exchange_Golang_project/utils/decimal"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransferRequest struct {
+	Amount   decimal.Decimal `json:"amount" example:"500.00"`
+	Currency string          `json:"currency" example:"USD"`
+}
+
+type RejectTransferRequest struct {
+	Reason string `json:"reason" example:"Bank details could not be verified"`
+}
+
+// RequestDeposit godoc
+// @Summary Request a cash deposit
+// @Description Records a PENDING deposit for the user. The cash is credited once an admin approves the deposit and the payment rail collects it. Only the login linked to the account and admins may request it. currency defaults to the base currency (USD); other currencies need a configured exchange rate.
+// @Tags Transfer
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Param transfer body TransferRequest true "Amount to deposit"
+// @Param Idempotency-Key header string false "Unique key that makes the request safe to retry"
+// @Success 201 {object} payments.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/deposits [post]
+func RequestDeposit(c *gin.Context) {
+	requestTransfer(c, payments.Deposit)
+}
+
+// RequestWithdrawal godoc
+// @Summary Request a cash withdrawal
+// @Description Records a PENDING withdrawal for the user and holds the amount from their cash balance until the withdrawal is paid out or rejected. Only the login linked to the account and admins may request it. Users may withdraw up to their settled cash balance in the currency, which excludes proceeds of trades that have not settled yet and defaults to the base currency (USD); margin accounts are further limited to their excess equity.
+// @Tags Transfer
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Param transfer body TransferRequest true "Amount to withdraw"
+// @Param Idempotency-Key header string false "Unique key that makes the request safe to retry"
+// @Success 201 {object} payments.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/withdrawals [post]
+func RequestWithdrawal(c *gin.Context) {
+	requestTransfer(c, payments.Withdrawal)
+}
+
+func requestTransfer(c *gin.Context, direction string) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	var input TransferRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+	if input.Currency == "" {
+		input.Currency = fx.Base
+	}
+
+	userID, ok := lookupUserID(c, db)
+	if !ok {
+		return
+	}
+
+	var transfer *payments.Transfer
+	err := engine.RunInTx(db, func(tx *sql.Tx) error {
+		var err error
+		transfer, err = payments.Request(tx, userID, direction, input.Currency, input.Amount)
+		return err
+	})
+	if err != nil {
+		transferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// GetUserTransfers godoc
+// @Summary List a user's deposits and withdrawals
+// @Description Retrieves the user's cash transfers, newest first, optionally filtered by status. Only the login linked to the account and admins may list them.
+// @Tags Transfer
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Param status query string false "Transfer status" Enums(PENDING, APPROVED, REJECTED, COMPLETED)
+// @Success 200 {array} payments.Transfer
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/transfers [get]
+func GetUserTransfers(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	userID, ok := lookupUserID(c, db)
+	if !ok {
+		return
+	}
+
+	transfers, err := payments.List(db, payments.Filter{UserID: userID, Status: c.Query("status")})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transfers)
+}
+
+// GetTransfers godoc
+// @Summary List cash transfers for review
+// @Description Retrieves every deposit and withdrawal, newest first, optionally filtered by status. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param status query string false "Transfer status" Enums(PENDING, APPROVED, REJECTED, COMPLETED)
+// @Success 200 {array} payments.Transfer
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/transfers [get]
+func GetTransfers(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	transfers, err := payments.List(db, payments.Filter{Status: c.Query("status")})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transfers)
+}
+
+// ApproveTransfer godoc
+// @Summary Approve a cash transfer
+// @Description Approves a PENDING deposit or withdrawal and sends it to the payment rail. Once the rail accepts it, the cash is booked to the ledger and the transfer is COMPLETED. If the rail fails the transfer stays APPROVED and can be approved again to retry. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} payments.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/transfers/{id}/approve [post]
+func ApproveTransfer(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid transfer ID"})
+		return
+	}
+
+	transfer, err := payments.Approve(db, id, c.GetString("username"))
+	if err != nil {
+		transferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// RejectTransfer godoc
+// @Summary Reject a cash transfer
+// @Description Rejects a deposit or withdrawal that has not completed. The cash held by a rejected withdrawal is returned to the user. Requires an admin account.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Param rejection body RejectTransferRequest true "Reason for the rejection"
+// @Success 200 {object} payments.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/transfers/{id}/reject [post]
+func RejectTransfer(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid transfer ID"})
+		return
+	}
+
+	var input RejectTransferRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid input"})
+		return
+	}
+
+	var transfer *payments.Transfer
+	err = engine.RunInTx(db, func(tx *sql.Tx) error {
+		var err error
+		transfer, err = payments.Reject(tx, id, c.GetString("username"), input.Reason)
+		return err
+	})
+	if err != nil {
+		transferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// lookupUserID resolves the username path parameter, writing the error
+// response when it cannot. Only the login linked to the account and admins
+// may act on a user's cash.
+func lookupUserID(c *gin.Context, db *sql.DB) (int, bool) {
+	username := strings.TrimSpace(c.Param("username"))
+
+	var userID int
+	var owner sql.NullString
+	query := `
+		SELECT u.id, a.username
+		FROM users u
+		LEFT JOIN auth_user a ON a.id = u.auth_user_id
+		WHERE LOWER(u.username) = LOWER($1)`
+	err := db.QueryRow(query, username).Scan(&userID, &owner)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return 0, false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve user"})
+		return 0, false
+	}
+
+	if !owner.Valid || owner.String != c.GetString("username") {
+		isAdmin, err := middleware.IsAdmin(db, c.GetString("username"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve user"})
+			return 0, false
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "Access denied"})
+			return 0, false
+		}
+	}
+	return userID, true
+}
+
+// transferError writes the HTTP response for an error raised while working
+// with a cash transfer.
+func transferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, payments.ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Amount must be positive and in whole cents"})
+	case errors.Is(err, fx.ErrInvalidCurrency):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Currency must be a three-letter ISO 4217 code"})
+	case errors.Is(err, fx.ErrNoRate):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "No exchange rate is configured for the currency"})
+	case errors.Is(err, payments.ErrInsufficientCash):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Insufficient withdrawable cash"})
+	case errors.Is(err, payments.ErrTransferNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Transfer not found"})
+	case errors.Is(err, engine.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+	case errors.Is(err, payments.ErrTransferClosed):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Transfer can no longer change state"})
+	case errors.Is(err, payments.ErrRailFailed):
+		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "Payment rail failed; the transfer stays approved and can be retried"})
+	case errors.Is(err, payments.ErrNoRail):
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "No payment rail is configured"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to process transfer"})
+	}
+}
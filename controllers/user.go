@@ -6,31 +6,48 @@ import (
 	"database/sql"
 	"net/http"
 	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/middleware"
+	"stock_exchange_Golang_project/payments"
+	"stock_exchange_Golang_project/utils/decimal"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type User struct {
-	ID       int     `json:"id"`
-	Username string  `json:"username"`
-	Balance  float64 `json:"balance"`
+	ID          int             `json:"id"`
+	Username    string          `json:"username"`
+	Balance     decimal.Decimal `json:"balance"`
+	AccountType string          `json:"account_type"`
 }
 
 type UserRequest struct {
-	Username       string  `json:"username" example:"abdullah"`
-	InitialBalance float64 `json:"initial_balance" example:"1000.00"`
+	Username        string          `json:"username" example:"abdullah"`
+	InitialBalance  decimal.Decimal `json:"initial_balance" example:"1000.00"`
+	AccountType     string          `json:"account_type" example:"CASH" enums:"CASH,MARGIN"`
+	CostBasisMethod string          `json:"cost_basis_method" example:"FIFO" enums:"FIFO,LIFO,AVERAGE"`
+}
+
+type BuyingPowerResponse struct {
+	engine.Account
+	Ticker      string          `json:"ticker,omitempty"`
+	BuyingPower decimal.Decimal `json:"buying_power"`
 }
 
 // CreateUser godoc
 // @Summary Create a new user
-// @Description Saves new user data into the database.
+// @Description Saves new user data into the database and opens the user's cash and unsettled accounts in the ledger. An account named after the caller's login is linked to it, and each login may have one; only admins may open accounts under other names, which no login is linked to. A positive initial_balance is requested as a PENDING deposit, credited like any other once an admin approves it and the payment rail collects it. account_type defaults to CASH; MARGIN accounts may borrow against their equity and sell short. cost_basis_method decides which tax lots closing trades realize P&L against and defaults to FIFO.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Param user body controllers.UserRequest true "User data"
 // @Success 201 {object} controllers.SuccessResponse
 // @Failure 400 {object} controllers.ErrorResponse
+// @Failure 403 {object} controllers.ErrorResponse
+// @Failure 409 {object} controllers.ErrorResponse
 // @Failure 500 {object} controllers.ErrorResponse
 // @Security BearerAuth
 // @Router /api/users [post]
@@ -44,12 +61,91 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
-	query := `INSERT INTO users (username, balance) VALUES ($1, $2)`
-	_, err := db.Exec(query, input.Username, input.InitialBalance)
+	if input.AccountType == "" {
+		input.AccountType = engine.CashAccount
+	}
+	if input.AccountType != engine.CashAccount && input.AccountType != engine.MarginAccount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "AccountType must be either CASH or MARGIN"})
+		return
+	}
+
+	if input.CostBasisMethod == "" {
+		input.CostBasisMethod = engine.FIFO
+	}
+	if !engine.ValidCostBasisMethod(input.CostBasisMethod) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CostBasisMethod must be FIFO, LIFO or AVERAGE"})
+		return
+	}
+
+	if input.InitialBalance.IsNegative() || !input.InitialBalance.Equal(input.InitialBalance.Round(decimal.Cents, decimal.Down)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "InitialBalance must be a non-negative amount in whole cents"})
+		return
+	}
+
+	// An account named after the caller's login is linked to it; only admins
+	// may open accounts under other names, which no login is linked to.
+	caller := c.GetString("username")
+	var authUserID *int
+	if strings.EqualFold(strings.TrimSpace(input.Username), caller) {
+		var id int
+		if err := db.QueryRow(`SELECT id FROM auth_user WHERE username = $1`, caller).Scan(&id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+			return
+		}
+		authUserID = &id
+	} else {
+		isAdmin, err := middleware.IsAdmin(db, caller)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Username must match your login"})
+			return
+		}
+	}
+
+	tx, err := db.Begin()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
+	defer tx.Rollback()
+
+	if authUserID != nil {
+		var linked bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE auth_user_id = $1)`, *authUserID).Scan(&linked); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+			return
+		}
+		if linked {
+			c.JSON(http.StatusConflict, gin.H{"error": "Your login already has a trading account"})
+			return
+		}
+	}
+
+	var userID int
+	query := `INSERT INTO users (username, account_type, cost_basis_method, auth_user_id) VALUES ($1, $2, $3, $4) RETURNING id`
+	if err := tx.QueryRow(query, input.Username, input.AccountType, input.CostBasisMethod, authUserID).Scan(&userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	if err := ledger.OpenUserAccount(tx, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open ledger account"})
+		return
+	}
+	if input.InitialBalance.IsPositive() {
+		if _, err := payments.Request(tx, userID, payments.Deposit, fx.Base, input.InitialBalance); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request initial deposit"})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "User created successfully"})
 }
@@ -73,8 +169,8 @@ func GetUser(c *gin.Context) {
 	username := strings.TrimSpace(c.Param("username"))
 
 	var user User
-	query := `SELECT id, username, balance FROM users WHERE LOWER(username) = LOWER($1)`
-	err := db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.Balance)
+	query := `SELECT id, username, balance, account_type FROM users WHERE LOWER(username) = LOWER($1)`
+	err := db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.Balance, &user.AccountType)
 
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
@@ -86,3 +182,116 @@ func GetUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user)
 }
+
+// GetBuyingPower godoc
+// @Summary Get buying power for a user
+// @Description Values the user's cash and positions at last trade prices, converted to the base currency (USD), and returns how much of the base currency cash comes from trades that have not settled yet, the margin requirements, whether the account is in a margin call (which limits it to orders that reduce its positions), and the buying power available for the given ticker (or at the default 50% initial margin when no ticker is given). Cash accounts can only spend settled cash; margin accounts borrow against unsettled cash like any other equity. Only the login linked to the account and admins may view it.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Param ticker query string false "Stock ticker"
+// @Success 200 {object} BuyingPowerResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/buying-power [get]
+func GetBuyingPower(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	userID, ok := lookupUserID(c, db)
+	if !ok {
+		return
+	}
+
+	initialMargin := decimal.New(5, -1)
+	ticker := c.Query("ticker")
+	if ticker != "" {
+		var err error
+		initialMargin, err = engine.InitialMargin(db, ticker)
+		if err == engine.ErrStockNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Stock not found"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve stock"})
+			return
+		}
+	}
+
+	account, err := engine.LoadAccount(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to value account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, BuyingPowerResponse{
+		Account:     *account,
+		Ticker:      ticker,
+		BuyingPower: account.BuyingPower(initialMargin),
+	})
+}
+
+// GetLedger godoc
+// @Summary Get the ledger statement of a user
+// @Description Lists every posting to the user's cash account in a currency, newest first: trades, commissions, settled trade proceeds, conversions, deposits and withdrawals, each with the account balance after it was applied. currency defaults to the base currency (USD). Only the login linked to the account and admins may view it.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Param currency query string false "Currency code" default(USD)
+// @Success 200 {array} ledger.Line
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/ledger [get]
+func GetLedger(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	userID, ok := lookupUserID(c, db)
+	if !ok {
+		return
+	}
+
+	lines, err := ledger.Statement(db, userID, c.DefaultQuery("currency", fx.Base))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve ledger"})
+		return
+	}
+
+	c.JSON(http.StatusOK, lines)
+}
+
+// GetCashBalances godoc
+// @Summary Get the cash balances of a user
+// @Description Lists the settled cash the user holds in each currency: the base currency (USD) first, then every other currency with a non-zero balance. Proceeds of trades that have not settled yet are not included. Only the login linked to the account and admins may view them.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param username path string true "username"
+// @Success 200 {array} ledger.Cash
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{username}/balances [get]
+func GetCashBalances(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	userID, ok := lookupUserID(c, db)
+	if !ok {
+		return
+	}
+
+	balances, err := ledger.CashBalances(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve balances"})
+		return
+	}
+
+	c.JSON(http.StatusOK, balances)
+}
@@ -18,9 +18,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/stocks": {
-            "get": {
-                "description": "Retrieves all stock entries from the database.",
+        "/api/admin/calendar": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the exchange timezone, session times (HH:MM) and trading days (0 = Sunday), and optionally the settlement cycle: trades settle settlement_days trading days (0 to 5) after the trade date. The cycle applies to trades made from then on and is left unchanged when settlement_days is omitted. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -28,17 +33,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Stock"
+                    "Admin"
+                ],
+                "summary": "Update market calendar",
+                "parameters": [
+                    {
+                        "description": "Calendar",
+                        "name": "calendar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CalendarRequest"
+                        }
+                    }
                 ],
-                "summary": "Retrieve all stocks",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/controllers.Stock"
-                            }
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
                     "500": {
@@ -48,14 +73,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/admin/corporate-actions": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Saves new stock data into the database.",
+                "description": "Schedules a corporate action. A SPLIT turns every split_from shares into split_to shares (4-for-1 is split_from 1, split_to 4; a 1-for-10 reverse split is split_from 10, split_to 1). Before the open on the ex-date, positions, the filled and open shares of open orders and the stock's prices are restated in post-split shares, and fractional shares are cashed out at the adjusted price. A DIVIDEND pays dividend_per_share, in the stock's currency, on every share whose trade has settled by the end of the record date, recorded once the record date begins; short holders are charged it. Dividends are credited on the pay date. Dates are YYYY-MM-DD in the exchange timezone, ordered ex_date \u003c= record_date \u003c= pay_date, and ex_date must be after today. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -63,17 +90,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Stock"
+                    "Admin"
                 ],
-                "summary": "Create a new stock entry",
+                "summary": "Announce a split or dividend",
                 "parameters": [
                     {
-                        "description": "Stock data",
-                        "name": "stock",
+                        "description": "Corporate action",
+                        "name": "action",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.CreateStockRequest"
+                            "$ref": "#/definitions/controllers.CorporateActionRequest"
                         }
                     }
                 ],
@@ -81,7 +108,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/controllers.SuccessResponse"
+                            "$ref": "#/definitions/engine.CorporateAction"
                         }
                     },
                     "400": {
@@ -90,6 +117,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -99,14 +138,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/stocks/{ticker}": {
-            "get": {
+        "/api/admin/corporate-actions/process": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves stock details based on the provided ticker symbol.",
+                "description": "Applies every scheduled split whose ex-date has been reached, records the holders of every scheduled dividend whose record date has been reached and pays every dividend whose pay date has been reached, without waiting for the background processor. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -114,27 +153,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Stock"
-                ],
-                "summary": "Retrieve stock by ticker",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Stock Ticker",
-                        "name": "ticker",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "Process due corporate actions now",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.Stock"
+                            "$ref": "#/definitions/controllers.ProcessCorporateActionsResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
@@ -148,14 +178,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/transactions": {
-            "post": {
+        "/api/admin/corporate-actions/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Creates a new transaction record in the system.",
+                "description": "Withdraws a SCHEDULED corporate action: a split before its ex-date, a dividend before its record date. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -163,25 +193,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Transaction"
+                    "Admin"
                 ],
-                "summary": "Create a new transaction",
+                "summary": "Cancel a corporate action",
                 "parameters": [
                     {
-                        "description": "Transaction data",
-                        "name": "transaction",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/controllers.TransactionRequest"
-                        }
+                        "type": "integer",
+                        "description": "Corporate action ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.SuccessResponse"
+                            "$ref": "#/definitions/engine.CorporateAction"
                         }
                     },
                     "400": {
@@ -190,12 +218,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -205,14 +245,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/transactions/{username}": {
-            "get": {
+        "/api/admin/fx-rates/{currency}": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves a list of all transactions for a given user.",
+                "description": "Creates or replaces the rate of a currency, as the price of one unit of it in the base currency (USD). The base currency has no configurable rate. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -220,26 +260,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Transaction"
+                    "Admin"
                 ],
-                "summary": "Get all Transactions for a user",
+                "summary": "Set an exchange rate",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Username",
-                        "name": "username",
+                        "description": "Currency code",
+                        "name": "currency",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Exchange rate",
+                        "name": "rate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.FXRateRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/controllers.Transaction"
-                            }
+                            "$ref": "#/definitions/fx.Rate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
                     "500": {
@@ -251,14 +309,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/transactions/{username}/{start_time}/{end_time}": {
-            "get": {
+        "/api/admin/halts": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves transactions for a specific user within a given time range.",
+                "description": "Suspends order entry in a single ticker, or on the whole exchange when no ticker is given. Resting orders stay on the book and can still be cancelled. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -266,42 +324,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Transaction"
+                    "Admin"
                 ],
-                "summary": "Get Transactions for a user by timestamp",
+                "summary": "Halt trading",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Username of the user",
-                        "name": "username",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "format": "date",
-                        "description": "Start timestamp in YYYY-MM-DD format",
-                        "name": "start_time",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "format": "date",
-                        "description": "End timestamp in YYYY-MM-DD format",
-                        "name": "end_time",
-                        "in": "path",
-                        "required": true
+                        "description": "Halt",
+                        "name": "halt",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.HaltRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/controllers.Transaction"
-                            }
+                            "$ref": "#/definitions/engine.Halt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
                     "500": {
@@ -313,14 +366,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/users": {
+        "/api/admin/holidays": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Saves new user data into the database.",
+                "description": "Closes the exchange for a whole date (YYYY-MM-DD). Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -328,17 +381,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "Create a new user",
+                "summary": "Add a market holiday",
                 "parameters": [
                     {
-                        "description": "User data",
-                        "name": "user",
+                        "description": "Holiday",
+                        "name": "holiday",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.UserRequest"
+                            "$ref": "#/definitions/engine.Holiday"
                         }
                     }
                 ],
@@ -355,6 +408,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -364,14 +423,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/users/{username}": {
-            "get": {
+        "/api/admin/holidays/{date}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves user details based on the provided username.",
+                "description": "Reopens a date previously declared a holiday. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -379,14 +438,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "get user by username",
+                "summary": "Remove a market holiday",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "username",
-                        "name": "username",
+                        "format": "date",
+                        "description": "Holiday date in YYYY-MM-DD format",
+                        "name": "date",
                         "in": "path",
                         "required": true
                     }
@@ -395,7 +455,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.User"
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
                     "404": {
@@ -413,9 +479,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/authenticated": {
+        "/api/admin/reconciliation": {
             "get": {
-                "description": "Validate the JWT token",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Recomputes every user's cash from the ledger, the cash their trades moved from the transaction history, and their positions from their trades, share issuances and split adjustments, and reports each difference as a break: CASH_BALANCE, TRADE_CASH, POSITION or TAX_LOTS. Cash breaks are in the break's currency, the others in shares. Nothing is changed. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -423,18 +494,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth_user"
+                    "Admin"
                 ],
-                "summary": "Check if user is authenticated",
+                "summary": "Reconcile balances and holdings",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.SuccessResponse"
+                            "$ref": "#/definitions/reconcile.Report"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
@@ -442,9 +519,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/login": {
+        "/api/admin/reconciliation/corrections": {
             "post": {
-                "description": "Login with username and password",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Runs the same reconciliation and corrects the breaks it finds. The ledger and the transaction history are the books of record: cached cash balances, positions and tax lots are rewritten to agree with them, and trade cash is corrected by a TRADE_ADJUSTMENT journal entry against the exchange's RECONCILIATION account. Positions are only corrected to whole shares; breaks left uncorrected are reported with corrected false. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -452,35 +534,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth_user"
-                ],
-                "summary": "Login user",
-                "parameters": [
-                    {
-                        "description": "Login credentials",
-                        "name": "creds",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/controllers.LoginCredentials"
-                        }
-                    }
+                    "Admin"
                 ],
+                "summary": "Reconcile and correct breaks",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/controllers.ErrorResponse"
+                            "$ref": "#/definitions/reconcile.Report"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
@@ -494,9 +559,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/register": {
+        "/api/admin/resume": {
             "post": {
-                "description": "Add a new user",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lifts the halts on a single ticker, or the exchange-wide halts when no ticker is given. Requires an admin account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -504,25 +574,343 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth_user"
+                    "Admin"
                 ],
-                "summary": "Register auth-user",
+                "summary": "Resume trading",
                 "parameters": [
                     {
-                        "description": "A_user Data",
-                        "name": "user",
+                        "description": "Ticker to resume",
+                        "name": "resume",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.A_user"
+                            "$ref": "#/definitions/controllers.ResumeRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.SignupResponse"
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/settlements/process": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Settles every pending obligation whose settlement date has been reached, without waiting for the background settlement run. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Settle due trades now",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SettleTradesResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/transfers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves every deposit and withdrawal, newest first, optionally filtered by status. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List cash transfers for review",
+                "parameters": [
+                    {
+                        "enum": [
+                            "PENDING",
+                            "APPROVED",
+                            "REJECTED",
+                            "COMPLETED"
+                        ],
+                        "type": "string",
+                        "description": "Transfer status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/payments.Transfer"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/transfers/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Approves a PENDING deposit or withdrawal and sends it to the payment rail. Once the rail accepts it, the cash is booked to the ledger and the transfer is COMPLETED. If the rail fails the transfer stays APPROVED and can be approved again to retry. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Approve a cash transfer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/payments.Transfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/transfers/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rejects a deposit or withdrawal that has not completed. The cash held by a rejected withdrawal is returned to the user. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reject a cash transfer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Transfer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason for the rejection",
+                        "name": "rejection",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RejectTransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/payments.Transfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Opens a Server-Sent Events stream of the feed of the trading account linked to the caller's login. ORDER events carry the new state of one of their orders, FILL events one side of a trade they took part in, and BALANCE events a change to their cash in one currency with the balance after it. Each event's id is its seq, numbered from 1 per user in commit order, and its data is the feed event as JSON. Send the Last-Event-ID header, or the last_event_id query parameter, to resume after that event; without it the stream starts with the next event. A comment is sent every 15 seconds while the stream is idle.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Stream the caller's order, fill and balance events",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/feed.Event"
+                            }
                         }
                     },
                     "400": {
@@ -531,6 +919,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -539,176 +933,3696 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "controllers.CreateStockRequest": {
+        },
+        "/api/fees": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves every fee tier. A fill is charged by the tier for its liquidity (MAKER or TAKER) with the highest min_monthly_volume the user has reached this calendar month; the fee is per_share * volume + percentage * notional + flat.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Fee"
+                ],
+                "summary": "List the commission schedule",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FeeSchedule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Saves the fee tier for a liquidity type and monthly volume threshold, replacing any tier already defined for that pair. per_share takes up to 4 decimal places, percentage is a fraction of the notional between 0 and 1 with up to 6, and flat is in cents. Returns 201 when the tier is new and 200 when it replaced one. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create or replace a fee tier",
+                "parameters": [
+                    {
+                        "description": "Fee tier",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.FeeScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.FeeSchedule"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.FeeSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/fees/revenue": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the balance of the exchange account credited with every commission charged. Commissions are charged in the currency of the trade: balance is the revenue in the base currency (USD), and balances lists the revenue in every currency, the base currency first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Fee"
+                ],
+                "summary": "Get exchange fee revenue",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.FeeRevenueResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/fees/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes a fee tier. Fills that no longer match any tier are charged no commission. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete a fee tier",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Fee tier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/fx-rates": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the configured exchange rates. Each rate is the price of one unit of the currency in the base currency (USD), and is used to value cash and positions held in that currency and to convert cash when buying stocks listed in it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "FX"
+                ],
+                "summary": "List exchange rates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/fx.Rate"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/market/calendar": {
+            "get": {
+                "description": "Returns the session times, trading days (0 = Sunday), settlement cycle and holidays of the exchange.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Market"
+                ],
+                "summary": "Get market calendar",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/engine.Calendar"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/market/status": {
+            "get": {
+                "description": "Returns the current trading phase (PRE_OPEN, OPEN or CLOSED), the next session open and close, and every trading halt in force. Orders are only accepted while the phase is OPEN.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Market"
+                ],
+                "summary": "Get market status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MarketStatusResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/market/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upgrades the connection to a WebSocket that streams quotes, trade prints and order book changes. Send {\"action\": \"subscribe\", \"tickers\": [\"AAPL\"]} to subscribe and \"unsubscribe\" to stop. Each subscribed ticker starts with a snapshot of its quote and book, followed by tick, trade and book messages; book messages carry the new quantity of each changed price level, zero removing it. Every message carries a seq numbered consecutively from 1 on the connection, a heartbeat message is sent every 15 seconds, and peers are pinged to detect dead connections. Clients that fall 256 messages behind are disconnected with close code 1013 and should reconnect and resubscribe.",
+                "tags": [
+                    "Market"
+                ],
+                "summary": "Stream live market data",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/stream.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the caller's orders, newest first, optionally filtered by ticker and status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "List orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock ticker",
+                        "name": "ticker",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "NEW",
+                            "PARTIALLY_FILLED",
+                            "FILLED",
+                            "CANCELLED",
+                            "REJECTED",
+                            "EXPIRED"
+                        ],
+                        "type": "string",
+                        "description": "Order status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Order"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a single order of the caller and its current state. Orders of other users are not found.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Get order by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Takes a NEW or PARTIALLY_FILLED order of the caller off the book. Shares already filled are unaffected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Cancel an open order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the limit price, stop price or total quantity of an open order of the caller. Reducing the quantity keeps the order's queue priority; changing a price or increasing the quantity loses it. The amended order is matched again if it now crosses the book.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Amend an open order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to replace",
+                        "name": "amendment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AmendOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stocks": {
+            "get": {
+                "description": "Retrieves all listed stocks; delisted stocks are left out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stock"
+                ],
+                "summary": "Retrieve all stocks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.Stock"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Saves new stock data into the database. The stock trades in currency, which defaults to the base currency (USD). When initial_holder and initial_shares are given, the shares are issued to that user at the listing price. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create a new stock entry",
+                "parameters": [
+                    {
+                        "description": "Stock data",
+                        "name": "stock",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateStockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stocks/{ticker}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves stock details based on the provided ticker symbol. delisted_at is set once the stock has been delisted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stock"
+                ],
+                "summary": "Retrieve stock by ticker",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Stock"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets the last price and the initial and maintenance margin rates of the ticker; all three are required. The new price must lie inside the stock's static band and the dynamic band around the last price; it is added to the price history, does not move the reference price, and while the stock is tradable triggers stop orders or trips the circuit breaker like a trade. The change, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Replace the settings of a stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock settings",
+                        "name": "stock",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.StockUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stops trading in the ticker for good. Open orders in it are cancelled and scheduled corporate actions are called off; holders keep their positions and dividends already recorded are still paid. The delisting, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delist a stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reason for delisting",
+                        "name": "reason",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes any of the last price and the initial and maintenance margin rates of the ticker; settings left out are kept. The new price must lie inside the stock's static band and the dynamic band around the last price; it is added to the price history, does not move the reference price, and while the stock is tradable triggers stop orders or trips the circuit breaker like a trade. The change, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update the settings of a stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock settings",
+                        "name": "stock",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.StockUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stocks/{ticker}/candles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Aggregates the ticker's recorded prices into open, high, low, close and volume bars of 1m, 1h or 1d, oldest first. Each bar starts at its time; daily bars follow calendar days in the exchange timezone. Prices and volumes are restated in current shares when the stock has split since. from and to take RFC 3339 timestamps or YYYY-MM-DD dates; to defaults to now and from to 500 bars before to. Bars without prices are left out, and at most 5000 bars may be requested at once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stock"
+                ],
+                "summary": "Get OHLCV candles of a stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "1m",
+                            "1h",
+                            "1d"
+                        ],
+                        "type": "string",
+                        "default": "1m",
+                        "description": "Bar size",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, exclusive",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/engine.Candle"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stocks/{ticker}/corporate-actions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the splits and dividends announced for the ticker, latest ex-date first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stock"
+                ],
+                "summary": "List corporate actions of a stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/engine.CorporateAction"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stocks/{ticker}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns who listed, changed and delisted the ticker and when, oldest first. Each entry lists the old and new value of every setting that changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stock"
+                ],
+                "summary": "Get the change history of a stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/engine.StockChange"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stocks/{ticker}/margin": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets the initial and maintenance margin rates applied to positions in the ticker. Rates are fractions of position value; the maintenance rate may not exceed the initial rate. The change, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Configure margin rates for a stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Margin rates",
+                        "name": "margin",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MarginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stocks/{ticker}/price-bands": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the reference price, static and dynamic price bands and circuit breaker settings of the ticker. Bands and the threshold are fractions of a price; null means the check is disabled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stock"
+                ],
+                "summary": "Get price bands of a stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/engine.Bands"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the reference price, price bands and circuit breaker settings of the ticker. Limit orders priced outside the static band around the reference price are rejected, and no trade may print outside the static band or the dynamic band around the last trade. When trades within the breaker window move more than the threshold, trading in the ticker halts for the configured number of minutes. Send null for a band or threshold to disable it. The change, with the admin who made it and the optional reason, is recorded in the stock's history. Requires an admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Configure price bands of a stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock Ticker",
+                        "name": "ticker",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Price bands",
+                        "name": "bands",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PriceBandsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/transactions": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Places a BUY or SELL order for the trading account linked to the caller's login on the ticker's order book and matches it against resting orders by price-time priority. order_type is one of MARKET, LIMIT (requires limit_price), STOP (requires stop_price) or STOP_LIMIT (requires both); when omitted it is inferred from the prices given. Market orders never rest, and stop orders wait until the last trade price reaches stop_price. time_in_force is one of DAY (default, expires at session close), GTC, IOC (remainder cancelled), FOK (rejected unless filled in full) or GTD (expires at expires_at). Orders are only accepted while the market is open and the ticker is not halted. Margin accounts in a margin call, whose equity is below their maintenance requirement, may only place orders that reduce their existing positions until the call is met. Each fill is recorded as a transaction for both parties. Stocks listed in another currency trade in that currency: a buyer short of cash in it has the difference converted from their base currency cash at the configured exchange rate, and the rate is recorded on the transaction. Send an Idempotency-Key header to make retries safe: a repeated key replays the first response instead of placing the order again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Submit a new order",
+                "parameters": [
+                    {
+                        "description": "Transaction data",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TransactionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes the request safe to retry",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/transactions/{username}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves a list of all transactions for a given user. Trades are reported as executed; transaction_volume * split_factor restates the volume in shares after any later splits. realized_pnl is the profit or loss, before fees, that the trade realized by closing shares under the account's cost-basis method.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Get all Transactions for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.Transaction"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/transactions/{username}/{start_time}/{end_time}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves transactions for a specific user within a given time range.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Get Transactions for a user by timestamp",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username of the user",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Start timestamp in YYYY-MM-DD format",
+                        "name": "start_time",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "End timestamp in YYYY-MM-DD format",
+                        "name": "end_time",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.Transaction"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Saves new user data into the database and opens the user's cash and unsettled accounts in the ledger. An account named after the caller's login is linked to it, and each login may have one; only admins may open accounts under other names, which no login is linked to. A positive initial_balance is requested as a PENDING deposit, credited like any other once an admin approves it and the payment rail collects it. account_type defaults to CASH; MARGIN accounts may borrow against their equity and sell short. cost_basis_method decides which tax lots closing trades realize P\u0026L against and defaults to FIFO.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves user details based on the provided username.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "get user by username",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.User"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/balances": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the settled cash the user holds in each currency: the base currency (USD) first, then every other currency with a non-zero balance. Proceeds of trades that have not settled yet are not included. Only the login linked to the account and admins may view them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get the cash balances of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ledger.Cash"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/buying-power": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Values the user's cash and positions at last trade prices, converted to the base currency (USD), and returns how much of the base currency cash comes from trades that have not settled yet, the margin requirements, whether the account is in a margin call (which limits it to orders that reduce its positions), and the buying power available for the given ticker (or at the default 50% initial margin when no ticker is given). Cash accounts can only spend settled cash; margin accounts borrow against unsettled cash like any other equity. Only the login linked to the account and admins may view it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get buying power for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Stock ticker",
+                        "name": "ticker",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BuyingPowerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/cost-basis-method": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Chooses which shares the user's closing trades dispose of when realizing P\u0026L: FIFO closes the oldest tax lots first, LIFO the newest, and AVERAGE closes at the position's average cost. The method applies to trades from now on; P\u0026L already realized is not restated. Only the login linked to the account and admins may change it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Set the cost-basis method of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cost-basis method",
+                        "name": "method",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CostBasisRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/deposits": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Records a PENDING deposit for the user. The cash is credited once an admin approves the deposit and the payment rail collects it. Only the login linked to the account and admins may request it. currency defaults to the base currency (USD); other currencies need a configured exchange rate.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transfer"
+                ],
+                "summary": "Request a cash deposit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Amount to deposit",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TransferRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes the request safe to retry",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/payments.Transfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/ledger": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists every posting to the user's cash account in a currency, newest first: trades, commissions, settled trade proceeds, conversions, deposits and withdrawals, each with the account balance after it was applied. currency defaults to the base currency (USD). Only the login linked to the account and admins may view it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get the ledger statement of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "USD",
+                        "description": "Currency code",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ledger.Line"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/portfolio": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Values every open position at the stock's last trade price and reports its cost basis, unrealized P\u0026L and the P\u0026L realized in the ticker so far, in the ticker's currency. Cost basis follows the account's cost-basis method: the open tax lots under FIFO and LIFO, the average cost under AVERAGE. Totals are in the base currency (USD): market value and cost basis at current exchange rates, realized P\u0026L and fees at the rates of the trades. Realized P\u0026L is before fees; equity is cash plus market value. Only the login linked to the account and admins may view it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get the portfolio summary of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/engine.Portfolio"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/positions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the quantity and average cost held by a user in every ticker. settled_quantity excludes shares bought or sold in trades that have not settled yet. Only the login linked to the account and admins may view them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get positions for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Position"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/settlements": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists what each side of the user's trades delivers and receives on its settlement date, latest trade first: quantity shares and amount cash in the trade's currency, commission included, each positive when received. Trades settle a number of trading days after the trade date set by the market calendar. Until then, the cash received waits in the user's unsettled account, where cash accounts cannot spend it and no account can withdraw it, and the shares received or delivered are left out of the position's settled_quantity; settlement moves both to the settled side. status filters by PENDING or SETTLED. Only the login linked to the account and admins may view them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get the settlement obligations of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "PENDING",
+                            "SETTLED"
+                        ],
+                        "type": "string",
+                        "description": "Settlement status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/engine.Settlement"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/transfers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the user's cash transfers, newest first, optionally filtered by status. Only the login linked to the account and admins may list them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transfer"
+                ],
+                "summary": "List a user's deposits and withdrawals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "PENDING",
+                            "APPROVED",
+                            "REJECTED",
+                            "COMPLETED"
+                        ],
+                        "type": "string",
+                        "description": "Transfer status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/payments.Transfer"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{username}/withdrawals": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Records a PENDING withdrawal for the user and holds the amount from their cash balance until the withdrawal is paid out or rejected. Only the login linked to the account and admins may request it. Users may withdraw up to their settled cash balance in the currency, which excludes proceeds of trades that have not settled yet and defaults to the base currency (USD); margin accounts are further limited to their excess equity.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transfer"
+                ],
+                "summary": "Request a cash withdrawal",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Amount to withdraw",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TransferRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes the request safe to retry",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/payments.Transfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/authenticated": {
+            "get": {
+                "description": "Validate the JWT token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth_user"
+                ],
+                "summary": "Check if user is authenticated",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login": {
+            "post": {
+                "description": "Login with username and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth_user"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "description": "Login credentials",
+                        "name": "creds",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LoginCredentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/register": {
+            "post": {
+                "description": "Add a new user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth_user"
+                ],
+                "summary": "Register auth-user",
+                "parameters": [
+                    {
+                        "description": "A_user Data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.A_user"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SignupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controllers.AmendOrderRequest": {
+            "type": "object",
+            "properties": {
+                "limit_price": {
+                    "type": "string",
+                    "example": "151.00"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "stop_price": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.BuyingPowerResponse": {
+            "type": "object",
+            "properties": {
+                "account_type": {
+                    "type": "string"
+                },
+                "buying_power": {
+                    "type": "string"
+                },
+                "cash": {
+                    "type": "string"
+                },
+                "equity": {
+                    "type": "string"
+                },
+                "excess_equity": {
+                    "type": "string"
+                },
+                "foreign_cash": {
+                    "type": "string"
+                },
+                "initial_requirement": {
+                    "type": "string"
+                },
+                "maintenance_requirement": {
+                    "type": "string"
+                },
+                "margin_call": {
+                    "type": "boolean"
+                },
+                "market_value": {
+                    "type": "string"
+                },
+                "settled_cash": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "unsettled_cash": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.CalendarRequest": {
+            "type": "object",
+            "properties": {
+                "close": {
+                    "type": "string",
+                    "example": "16:00"
+                },
+                "open": {
+                    "type": "string",
+                    "example": "09:30"
+                },
+                "pre_open": {
+                    "type": "string",
+                    "example": "04:00"
+                },
+                "settlement_days": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/New_York"
+                },
+                "trading_days": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "controllers.CorporateActionRequest": {
+            "type": "object",
+            "properties": {
+                "action_type": {
+                    "type": "string",
+                    "enum": [
+                        "SPLIT",
+                        "DIVIDEND"
+                    ],
+                    "example": "SPLIT"
+                },
+                "dividend_per_share": {
+                    "type": "string",
+                    "example": "0.24"
+                },
+                "ex_date": {
+                    "type": "string",
+                    "example": "2025-01-15"
+                },
+                "pay_date": {
+                    "type": "string",
+                    "example": "2025-01-31"
+                },
+                "record_date": {
+                    "type": "string",
+                    "example": "2025-01-15"
+                },
+                "split_from": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "split_to": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "ticker": {
+                    "type": "string",
+                    "example": "AAPL"
+                }
+            }
+        },
+        "controllers.CostBasisRequest": {
+            "type": "object",
+            "properties": {
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "FIFO",
+                        "LIFO",
+                        "AVERAGE"
+                    ],
+                    "example": "FIFO"
+                }
+            }
+        },
+        "controllers.CreateStockRequest": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "initial_holder": {
+                    "type": "string",
+                    "example": "abdullah"
+                },
+                "initial_shares": {
+                    "type": "integer",
+                    "example": 1000
+                },
+                "price": {
+                    "type": "string",
+                    "example": "150.25"
+                },
+                "ticker": {
+                    "type": "string",
+                    "example": "AAPL"
+                }
+            }
+        },
+        "controllers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.FXRateRequest": {
+            "type": "object",
+            "properties": {
+                "rate": {
+                    "type": "string",
+                    "example": "1.0825"
+                }
+            }
+        },
+        "controllers.FeeRevenueResponse": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "string"
+                },
+                "balance": {
+                    "type": "string"
+                },
+                "balances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ledger.Cash"
+                    }
+                }
+            }
+        },
+        "controllers.FeeScheduleRequest": {
+            "type": "object",
+            "properties": {
+                "flat": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "liquidity": {
+                    "type": "string",
+                    "enum": [
+                        "MAKER",
+                        "TAKER"
+                    ],
+                    "example": "TAKER"
+                },
+                "min_monthly_volume": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "per_share": {
+                    "type": "string",
+                    "example": "0.003"
+                },
+                "percentage": {
+                    "type": "string",
+                    "example": "0"
+                }
+            }
+        },
+        "controllers.HaltRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "Pending news"
+                },
+                "ticker": {
+                    "type": "string",
+                    "example": "AAPL"
+                }
+            }
+        },
+        "controllers.LoginCredentials": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.MarginRequest": {
+            "type": "object",
+            "properties": {
+                "initial_margin": {
+                    "type": "string",
+                    "example": "0.5"
+                },
+                "maintenance_margin": {
+                    "type": "string",
+                    "example": "0.25"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Volatility review"
+                }
+            }
+        },
+        "controllers.MarketStatusResponse": {
+            "type": "object",
+            "properties": {
+                "halts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/engine.Halt"
+                    }
+                },
+                "next_close": {
+                    "type": "string"
+                },
+                "next_open": {
+                    "type": "string"
+                },
+                "phase": {
+                    "type": "string",
+                    "example": "OPEN"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/New_York"
+                }
+            }
+        },
+        "controllers.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "fills": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/engine.Fill"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "order": {
+                    "$ref": "#/definitions/models.Order"
+                }
+            }
+        },
+        "controllers.PriceBandsRequest": {
+            "type": "object",
+            "properties": {
+                "circuit_breaker_halt_minutes": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "circuit_breaker_threshold": {
+                    "type": "string",
+                    "example": "0.1"
+                },
+                "circuit_breaker_window_seconds": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "dynamic_band": {
+                    "type": "string",
+                    "example": "0.05"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Wider bands for earnings day"
+                },
+                "reference_price": {
+                    "type": "string",
+                    "example": "150.25"
+                },
+                "static_band": {
+                    "type": "string",
+                    "example": "0.2"
+                }
+            }
+        },
+        "controllers.ProcessCorporateActionsResponse": {
+            "type": "object",
+            "properties": {
+                "processed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.RejectTransferRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "Bank details could not be verified"
+                }
+            }
+        },
+        "controllers.ResumeRequest": {
+            "type": "object",
+            "properties": {
+                "ticker": {
+                    "type": "string",
+                    "example": "AAPL"
+                }
+            }
+        },
+        "controllers.SettleTradesResponse": {
+            "type": "object",
+            "properties": {
+                "settled": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.SignupResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.Stock": {
+            "type": "object",
+            "required": [
+                "price",
+                "ticker"
+            ],
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "delisted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "initial_margin": {
+                    "type": "string"
+                },
+                "maintenance_margin": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.StockUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "initial_margin": {
+                    "type": "string",
+                    "example": "0.5"
+                },
+                "maintenance_margin": {
+                    "type": "string",
+                    "example": "0.25"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "150.25"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Relisted at auction price"
+                }
+            }
+        },
+        "controllers.SuccessResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.Transaction": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "fee": {
+                    "type": "string"
+                },
+                "fx_rate": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "liquidity": {
+                    "type": "string"
+                },
+                "realized_pnl": {
+                    "type": "string"
+                },
+                "split_factor": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "transaction_price": {
+                    "type": "string"
+                },
+                "transaction_type": {
+                    "type": "string"
+                },
+                "transaction_volume": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.TransactionRequest": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-12-31T16:00:00Z"
+                },
+                "limit_price": {
+                    "type": "string",
+                    "example": "150.25"
+                },
+                "order_type": {
+                    "type": "string",
+                    "enum": [
+                        "MARKET",
+                        "LIMIT",
+                        "STOP",
+                        "STOP_LIMIT"
+                    ],
+                    "example": "LIMIT"
+                },
+                "stop_price": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "ticker": {
+                    "type": "string",
+                    "example": "tia"
+                },
+                "time_in_force": {
+                    "type": "string",
+                    "enum": [
+                        "DAY",
+                        "GTC",
+                        "IOC",
+                        "FOK",
+                        "GTD"
+                    ],
+                    "example": "DAY"
+                },
+                "transaction_type": {
+                    "type": "string",
+                    "example": "BUY"
+                },
+                "transaction_volume": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "controllers.TransferRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "500.00"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "controllers.User": {
+            "type": "object",
+            "properties": {
+                "account_type": {
+                    "type": "string"
+                },
+                "balance": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.UserRequest": {
+            "type": "object",
+            "properties": {
+                "account_type": {
+                    "type": "string",
+                    "enum": [
+                        "CASH",
+                        "MARGIN"
+                    ],
+                    "example": "CASH"
+                },
+                "cost_basis_method": {
+                    "type": "string",
+                    "enum": [
+                        "FIFO",
+                        "LIFO",
+                        "AVERAGE"
+                    ],
+                    "example": "FIFO"
+                },
+                "initial_balance": {
+                    "type": "string",
+                    "example": "1000.00"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "abdullah"
+                }
+            }
+        },
+        "engine.Bands": {
+            "type": "object",
+            "properties": {
+                "circuit_breaker_halt_minutes": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "circuit_breaker_threshold": {
+                    "type": "string",
+                    "example": "0.1"
+                },
+                "circuit_breaker_window_seconds": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "dynamic_band": {
+                    "type": "string",
+                    "example": "0.05"
+                },
+                "reference_price": {
+                    "type": "string",
+                    "example": "150.25"
+                },
+                "static_band": {
+                    "type": "string",
+                    "example": "0.2"
+                }
+            }
+        },
+        "engine.Calendar": {
+            "type": "object",
+            "properties": {
+                "close": {
+                    "type": "string",
+                    "example": "16:00"
+                },
+                "holidays": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/engine.Holiday"
+                    }
+                },
+                "open": {
+                    "type": "string",
+                    "example": "09:30"
+                },
+                "pre_open": {
+                    "type": "string",
+                    "example": "04:00"
+                },
+                "settlement_days": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/New_York"
+                },
+                "trading_days": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "engine.Candle": {
+            "type": "object",
+            "properties": {
+                "close": {
+                    "type": "string"
+                },
+                "high": {
+                    "type": "string"
+                },
+                "low": {
+                    "type": "string"
+                },
+                "open": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "trades": {
+                    "type": "integer"
+                },
+                "volume": {
+                    "type": "string"
+                }
+            }
+        },
+        "engine.CorporateAction": {
+            "type": "object",
+            "properties": {
+                "action_type": {
+                    "type": "string",
+                    "enum": [
+                        "SPLIT",
+                        "DIVIDEND"
+                    ],
+                    "example": "SPLIT"
+                },
+                "announced_by": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dividend_per_share": {
+                    "type": "string",
+                    "example": "0.24"
+                },
+                "ex_date": {
+                    "type": "string",
+                    "example": "2025-01-15"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pay_date": {
+                    "type": "string",
+                    "example": "2025-01-31"
+                },
+                "processed_at": {
+                    "type": "string"
+                },
+                "record_date": {
+                    "type": "string",
+                    "example": "2025-01-15"
+                },
+                "split_from": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "split_to": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string",
+                    "example": "AAPL"
+                }
+            }
+        },
+        "engine.FieldChange": {
+            "type": "object",
+            "properties": {
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string"
+                }
+            }
+        },
+        "engine.Fill": {
+            "type": "object",
+            "properties": {
+                "buy_order_id": {
+                    "type": "integer"
+                },
+                "buyer_fee": {
+                    "type": "string"
+                },
+                "buyer_id": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "fx_rate": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "string"
+                },
+                "sell_order_id": {
+                    "type": "integer"
+                },
+                "seller_fee": {
+                    "type": "string"
+                },
+                "seller_id": {
+                    "type": "integer"
+                },
+                "settlement_date": {
+                    "type": "string"
+                },
+                "taker_side": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "trade_date": {
+                    "type": "string"
+                },
+                "volume": {
+                    "type": "integer"
+                }
+            }
+        },
+        "engine.Halt": {
+            "type": "object",
+            "properties": {
+                "halted_at": {
+                    "type": "string"
+                },
+                "halted_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resume_at": {
+                    "type": "string"
+                },
+                "resumed_at": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                }
+            }
+        },
+        "engine.Holiday": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2024-12-25"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Christmas Day"
+                }
+            }
+        },
+        "engine.Portfolio": {
+            "type": "object",
+            "properties": {
+                "cash": {
+                    "type": "string"
+                },
+                "cost_basis": {
+                    "type": "string"
+                },
+                "cost_basis_method": {
+                    "type": "string"
+                },
+                "equity": {
+                    "type": "string"
+                },
+                "fees": {
+                    "type": "string"
+                },
+                "market_value": {
+                    "type": "string"
+                },
+                "positions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/engine.PortfolioPosition"
+                    }
+                },
+                "realized_pnl": {
+                    "type": "string"
+                },
+                "unrealized_pnl": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "engine.PortfolioPosition": {
             "type": "object",
             "properties": {
-                "price": {
-                    "type": "number",
-                    "example": 150.25
+                "average_cost": {
+                    "type": "string"
+                },
+                "cost_basis": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "last_price": {
+                    "type": "string"
+                },
+                "market_value": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "realized_pnl": {
+                    "type": "string"
                 },
                 "ticker": {
-                    "type": "string",
-                    "example": "AAPL"
+                    "type": "string"
+                },
+                "unrealized_pnl": {
+                    "type": "string"
                 }
             }
         },
-        "controllers.ErrorResponse": {
+        "engine.Settlement": {
             "type": "object",
             "properties": {
-                "error": {
+                "amount": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "settled_at": {
+                    "type": "string"
+                },
+                "settlement_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticker": {
                     "type": "string"
+                },
+                "trade_date": {
+                    "type": "string"
+                },
+                "transaction_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "controllers.LoginCredentials": {
+        "engine.StockChange": {
             "type": "object",
             "properties": {
-                "password": {
+                "action": {
                     "type": "string"
                 },
-                "username": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/engine.FieldChange"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "ticker": {
                     "type": "string"
                 }
             }
         },
-        "controllers.LoginResponse": {
+        "feed.Event": {
             "type": "object",
             "properties": {
-                "token": {
+                "created_at": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "controllers.SignupResponse": {
+        "fx.Rate": {
             "type": "object",
             "properties": {
-                "message": {
+                "currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "1.0825"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "ledger.Cash": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "string",
+                    "example": "250.00"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "EUR"
+                }
+            }
+        },
+        "ledger.Line": {
+            "type": "object",
+            "properties": {
+                "amount": {
                     "type": "string"
                 },
-                "token": {
+                "balance": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "entry_id": {
+                    "type": "integer"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "controllers.Stock": {
+        "models.A_user": {
             "type": "object",
-            "required": [
-                "price",
-                "ticker"
-            ],
             "properties": {
+                "email": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "price": {
-                    "type": "number"
+                "password": {
+                    "type": "string"
                 },
-                "ticker": {
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "controllers.SuccessResponse": {
+        "models.FeeSchedule": {
             "type": "object",
             "properties": {
-                "message": {
+                "flat": {
                     "type": "string"
                 },
-                "token": {
+                "id": {
+                    "type": "integer"
+                },
+                "liquidity": {
+                    "type": "string"
+                },
+                "min_monthly_volume": {
+                    "type": "integer"
+                },
+                "per_share": {
+                    "type": "string"
+                },
+                "percentage": {
                     "type": "string"
                 }
             }
         },
-        "controllers.Transaction": {
+        "models.Order": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "filled_quantity": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "ticker": {
+                "order_type": {
                     "type": "string"
                 },
-                "timestamp": {
+                "price": {
                     "type": "string"
                 },
-                "transaction_price": {
-                    "type": "number"
+                "quantity": {
+                    "type": "integer"
                 },
-                "transaction_type": {
+                "side": {
                     "type": "string"
                 },
-                "transaction_volume": {
-                    "type": "integer"
+                "status": {
+                    "type": "string"
+                },
+                "stop_price": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "time_in_force": {
+                    "type": "string"
+                },
+                "triggered": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
                 },
                 "user_id": {
                     "type": "integer"
                 }
             }
         },
-        "controllers.TransactionRequest": {
+        "models.Position": {
             "type": "object",
             "properties": {
-                "ticker": {
-                    "type": "string",
-                    "example": "tia"
+                "average_cost": {
+                    "type": "string"
                 },
-                "transaction_type": {
-                    "type": "string",
-                    "example": "BUY"
+                "quantity": {
+                    "type": "integer"
                 },
-                "transaction_volume": {
-                    "type": "integer",
-                    "example": 10
+                "settled_quantity": {
+                    "type": "integer"
                 },
-                "username": {
-                    "type": "string",
-                    "example": "abdullah"
+                "ticker": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "controllers.User": {
+        "payments.Transfer": {
             "type": "object",
             "properties": {
-                "balance": {
-                    "type": "number"
+                "amount": {
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "username": {
+                "rail": {
+                    "type": "string"
+                },
+                "rail_reference": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reviewed_by": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "controllers.UserRequest": {
+        "reconcile.Break": {
             "type": "object",
             "properties": {
-                "initial_balance": {
-                    "type": "number",
-                    "example": 1000
+                "actual": {
+                    "type": "string"
+                },
+                "corrected": {
+                    "type": "boolean"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "difference": {
+                    "type": "string"
+                },
+                "expected": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 },
                 "username": {
-                    "type": "string",
-                    "example": "abdullah"
+                    "type": "string"
                 }
             }
         },
-        "models.A_user": {
+        "reconcile.Report": {
             "type": "object",
             "properties": {
-                "email": {
+                "breaks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/reconcile.Break"
+                    }
+                },
+                "corrected": {
+                    "type": "integer"
+                },
+                "run_at": {
                     "type": "string"
+                }
+            }
+        },
+        "stream.Message": {
+            "type": "object",
+            "properties": {
+                "book_seq": {
+                    "type": "integer"
                 },
-                "id": {
+                "data": {},
+                "seq": {
                     "type": "integer"
                 },
-                "password": {
+                "ticker": {
                     "type": "string"
                 },
-                "username": {
+                "time": {
+                    "type": "string"
+                },
+                "type": {
                     "type": "string"
                 }
             }
@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"stock_exchange_Golang_project/events"
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+var ErrPriceOutOfBand = errors.New("price outside the static price band")
+
+// CircuitBreaker is the name recorded as the author of automatic halts.
+const CircuitBreaker = "circuit-breaker"
+
+// Bands are the price limits configured for a ticker, as fractions of a
+// price. The static band is centred on the reference price and bounds every
+// limit price and trade; the dynamic band is centred on the last trade and
+// bounds the next one. The circuit breaker halts the ticker for HaltMinutes
+// when trades within WindowSeconds move more than BreakerThreshold. A nil
+// fraction disables that check.
+type Bands struct {
+	ReferencePrice   decimal.Decimal  `json:"reference_price" example:"150.25"`
+	StaticBand       *decimal.Decimal `json:"static_band" example:"0.2"`
+	DynamicBand      *decimal.Decimal `json:"dynamic_band" example:"0.05"`
+	BreakerThreshold *decimal.Decimal `json:"circuit_breaker_threshold" example:"0.1"`
+	WindowSeconds    int              `json:"circuit_breaker_window_seconds" example:"300"`
+	HaltMinutes      int              `json:"circuit_breaker_halt_minutes" example:"5"`
+}
+
+// Validate checks that the reference price and durations are positive and
+// that every configured fraction lies between 0 and 1.
+func (bands *Bands) Validate() error {
+	if !bands.ReferencePrice.IsPositive() {
+		return errors.New("reference_price must be positive")
+	}
+	if bands.WindowSeconds <= 0 || bands.HaltMinutes <= 0 {
+		return errors.New("circuit breaker window and halt duration must be positive")
+	}
+	one := decimal.FromInt(1)
+	for _, fraction := range []*decimal.Decimal{bands.StaticBand, bands.DynamicBand, bands.BreakerThreshold} {
+		if fraction != nil && (!fraction.IsPositive() || fraction.Cmp(one) >= 0) {
+			return errors.New("bands and threshold must lie strictly between 0 and 1")
+		}
+	}
+	return nil
+}
+
+// LoadBands reads the price bands configured for ticker.
+func LoadBands(q Querier, ticker string) (*Bands, error) {
+	var bands Bands
+	query := `
+		SELECT reference_price, static_band, dynamic_band, circuit_breaker_threshold,
+			circuit_breaker_window_seconds, circuit_breaker_halt_minutes
+		FROM stocks
+		WHERE ticker = $1`
+	err := q.QueryRow(query, ticker).Scan(&bands.ReferencePrice, &bands.StaticBand, &bands.DynamicBand,
+		&bands.BreakerThreshold, &bands.WindowSeconds, &bands.HaltMinutes)
+	if err == sql.ErrNoRows {
+		return nil, ErrStockNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &bands, nil
+}
+
+// SetBands replaces the price bands of ticker and records who changed them.
+// It returns nil when the bands are unchanged.
+func SetBands(tx *sql.Tx, ticker string, bands Bands, reason, changedBy string) (*StockChange, error) {
+	if _, err := lockListedStock(tx, ticker); err != nil {
+		return nil, err
+	}
+	current, err := LoadBands(tx, ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]FieldChange{}
+	for _, field := range []struct {
+		name       string
+		old, value string
+	}{
+		{"reference_price", current.ReferencePrice.String(), bands.ReferencePrice.String()},
+		{"static_band", fraction(current.StaticBand), fraction(bands.StaticBand)},
+		{"dynamic_band", fraction(current.DynamicBand), fraction(bands.DynamicBand)},
+		{"circuit_breaker_threshold", fraction(current.BreakerThreshold), fraction(bands.BreakerThreshold)},
+		{"circuit_breaker_window_seconds", strconv.Itoa(current.WindowSeconds), strconv.Itoa(bands.WindowSeconds)},
+		{"circuit_breaker_halt_minutes", strconv.Itoa(current.HaltMinutes), strconv.Itoa(bands.HaltMinutes)},
+	} {
+		if field.old != field.value {
+			changes[field.name] = FieldChange{Old: field.old, New: field.value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE stocks
+		SET reference_price = $1, static_band = $2, dynamic_band = $3, circuit_breaker_threshold = $4,
+			circuit_breaker_window_seconds = $5, circuit_breaker_halt_minutes = $6
+		WHERE ticker = $7`
+	_, err = tx.Exec(query, bands.ReferencePrice, bands.StaticBand, bands.DynamicBand, bands.BreakerThreshold,
+		bands.WindowSeconds, bands.HaltMinutes, ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	return RecordStockChange(tx, ticker, StockUpdated, changes, reason, changedBy)
+}
+
+// fraction formats a band or threshold for the stock history, empty when it
+// is disabled.
+func fraction(value *decimal.Decimal) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+// WithinStatic reports whether price lies inside the static band.
+func (bands *Bands) WithinStatic(price decimal.Decimal) bool {
+	return within(bands.StaticBand, bands.ReferencePrice, price)
+}
+
+// WithinDynamic reports whether price may trade after lastPrice.
+func (bands *Bands) WithinDynamic(lastPrice, price decimal.Decimal) bool {
+	return within(bands.DynamicBand, lastPrice, price)
+}
+
+func within(band *decimal.Decimal, centre, price decimal.Decimal) bool {
+	if band == nil || !centre.IsPositive() {
+		return true
+	}
+	return price.Sub(centre).Abs().Cmp(centre.Mul(*band)) <= 0
+}
+
+// tripCircuitBreaker halts ticker when the trade just printed at price has
+// moved the market more than the breaker threshold from the lowest or
+// highest trade within the breaker window. It reports whether the breaker
+// tripped. The trip price becomes the new reference price.
+func tripCircuitBreaker(tx *sql.Tx, ticker string, bands *Bands, price decimal.Decimal, now time.Time) (bool, error) {
+	if bands.BreakerThreshold == nil {
+		return false, nil
+	}
+
+	var low, high *decimal.Decimal
+	query := `
+		SELECT MIN(transaction_price / (transaction_volume * split_factor)), MAX(transaction_price / (transaction_volume * split_factor))
+		FROM transactions
+		WHERE ticker = $1 AND transaction_type = 'BUY' AND transaction_volume > 0 AND timestamp >= $2`
+	window := time.Duration(bands.WindowSeconds) * time.Second
+	if err := tx.QueryRow(query, ticker, now.Add(-window)).Scan(&low, &high); err != nil {
+		return false, err
+	}
+	if low == nil || high == nil {
+		return false, nil
+	}
+
+	threshold := *bands.BreakerThreshold
+	if price.Sub(*low).Cmp(low.Mul(threshold)) <= 0 && high.Sub(price).Cmp(high.Mul(threshold)) <= 0 {
+		return false, nil
+	}
+
+	resumeAt := now.Add(time.Duration(bands.HaltMinutes) * time.Minute)
+	reason := fmt.Sprintf("Circuit breaker: price moved more than %s%% within %s", threshold.MulInt(100), window)
+	halt, err := HaltTrading(tx, ticker, reason, CircuitBreaker, &resumeAt)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`UPDATE stocks SET reference_price = $1 WHERE ticker = $2`, price, ticker)
+	if err != nil {
+		return false, err
+	}
+	bands.ReferencePrice = price
+
+	emit(tx, events.Event{Type: events.Halt, Ticker: ticker, Data: halt, Time: now})
+	return true, nil
+}
+
+// ensureInBand rejects a limit price outside the static band of the order's
+// ticker. Such an order could never trade.
+func ensureInBand(tx *sql.Tx, order *models.Order) error {
+	if order.Price.IsZero() {
+		return nil
+	}
+	bands, err := LoadBands(tx, order.Ticker)
+	if err != nil {
+		return err
+	}
+	if !bands.WithinStatic(order.Price) {
+		return ErrPriceOutOfBand
+	}
+	return nil
+}
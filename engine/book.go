@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"database/sql"
+
+	"stock_exchange_Golang_project/events"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// Level is the open quantity resting at one price on one side of a book.
+type Level struct {
+	Price    decimal.Decimal `json:"price"`
+	Quantity int             `json:"quantity"`
+	Orders   int             `json:"orders"`
+}
+
+// Book is the aggregated order book of a ticker together with its last
+// trade price. Bids are sorted highest price first and asks lowest first.
+// Untriggered stops and expired orders are not part of the book. Seq numbers
+// the versions of the book in the order they were committed; every book
+// raised by the engine has the next number.
+type Book struct {
+	Ticker    string          `json:"ticker"`
+	Seq       int64           `json:"seq"`
+	LastPrice decimal.Decimal `json:"last_price"`
+	Bids      []Level         `json:"bids"`
+	Asks      []Level         `json:"asks"`
+}
+
+// LoadBook returns the current book of ticker.
+func LoadBook(q Querier, ticker string) (*Book, error) {
+	book := &Book{Ticker: ticker, Bids: []Level{}, Asks: []Level{}}
+	err := q.QueryRow(`SELECT price, book_seq FROM stocks WHERE ticker = $1`, ticker).Scan(&book.LastPrice, &book.Seq)
+	if err == sql.ErrNoRows {
+		return nil, ErrStockNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT side, price, SUM(quantity - filled_quantity), COUNT(*)
+		FROM orders
+		WHERE ticker = $1 AND status IN ('NEW', 'PARTIALLY_FILLED') AND triggered AND price IS NOT NULL
+			AND (expires_at IS NULL OR expires_at > now())
+		GROUP BY side, price
+		ORDER BY CASE WHEN side = 'BUY' THEN -price ELSE price END`
+	rows, err := q.Query(query, ticker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var side string
+		var level Level
+		if err := rows.Scan(&side, &level.Price, &level.Quantity, &level.Orders); err != nil {
+			return nil, err
+		}
+		if side == Buy {
+			book.Bids = append(book.Bids, level)
+		} else {
+			book.Asks = append(book.Asks, level)
+		}
+	}
+	return book, rows.Err()
+}
+
+// emitBook raises the book of ticker as it stands in tx under the next
+// sequence number. It is called once the orders or last price of the ticker
+// have changed. The stock row stays locked until tx ends, so books are
+// numbered in commit order.
+func emitBook(tx *sql.Tx, ticker string) error {
+	if _, err := tx.Exec(`UPDATE stocks SET book_seq = book_seq + 1 WHERE ticker = $1`, ticker); err != nil {
+		return err
+	}
+	book, err := LoadBook(tx, ticker)
+	if err != nil {
+		return err
+	}
+	emit(tx, events.Event{Type: events.Book, Ticker: ticker, Data: book})
+	return nil
+}
+
+// emitTrades raises a trade print for every fill.
+func emitTrades(tx *sql.Tx, fills []Fill) {
+	for _, fill := range fills {
+		emit(tx, events.Event{Type: events.Trade, Ticker: fill.Ticker, Data: fill, Time: fill.Timestamp})
+	}
+}
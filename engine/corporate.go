@@ -0,0 +1,593 @@
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"stock_exchange_Golang_project/events"
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	Split    = "SPLIT"
+	Dividend = "DIVIDEND"
+)
+
+const (
+	ActionScheduled = "SCHEDULED"
+	ActionRecorded  = "RECORDED"
+	ActionCompleted = "COMPLETED"
+	ActionCancelled = "CANCELLED"
+)
+
+var (
+	ErrInvalidAction  = errors.New("invalid corporate action")
+	ErrActionNotFound = errors.New("corporate action not found")
+	ErrActionClosed   = errors.New("corporate action can no longer be cancelled")
+)
+
+// dividendPlaces is the number of fractional digits of a dividend per share.
+const dividendPlaces = 6
+
+// CorporateAction is a split or cash dividend announced for a ticker. A
+// split turns every SplitFrom shares into SplitTo shares, so a reverse split
+// has SplitTo below SplitFrom. A dividend pays DividendPerShare, in the
+// ticker's currency, for every share held. Dates are YYYY-MM-DD in the
+// exchange timezone.
+//
+// A split takes effect before the open on the ex-date, when it is applied
+// to positions, open orders and prices. A dividend is due to the holders of
+// record: those whose shares have settled by the end of the record date,
+// which leaves out trades made from the ex-date on as long as the record
+// date falls before they settle. Entitlements are recorded once the record
+// date begins, so on a T+0 cycle trades made on the record date itself are
+// not counted. Dividends are paid on the pay date.
+type CorporateAction struct {
+	ID               int              `json:"id"`
+	Ticker           string           `json:"ticker" example:"AAPL"`
+	ActionType       string           `json:"action_type" example:"SPLIT" enums:"SPLIT,DIVIDEND"`
+	SplitFrom        int              `json:"split_from,omitempty" example:"1"`
+	SplitTo          int              `json:"split_to,omitempty" example:"4"`
+	DividendPerShare *decimal.Decimal `json:"dividend_per_share,omitempty" example:"0.24"`
+	ExDate           string           `json:"ex_date" example:"2025-01-15"`
+	RecordDate       string           `json:"record_date" example:"2025-01-15"`
+	PayDate          string           `json:"pay_date" example:"2025-01-31"`
+	Status           string           `json:"status"`
+	AnnouncedBy      string           `json:"announced_by"`
+	CreatedAt        time.Time        `json:"created_at"`
+	ProcessedAt      *time.Time       `json:"processed_at,omitempty"`
+}
+
+const actionColumns = `id, ticker, action_type, COALESCE(split_from, 0), COALESCE(split_to, 0), dividend_per_share,
+	to_char(ex_date, 'YYYY-MM-DD'), to_char(record_date, 'YYYY-MM-DD'), to_char(pay_date, 'YYYY-MM-DD'),
+	status, announced_by, created_at, processed_at`
+
+func scanAction(row scanner) (*CorporateAction, error) {
+	var action CorporateAction
+	err := row.Scan(&action.ID, &action.Ticker, &action.ActionType, &action.SplitFrom, &action.SplitTo, &action.DividendPerShare,
+		&action.ExDate, &action.RecordDate, &action.PayDate, &action.Status, &action.AnnouncedBy, &action.CreatedAt, &action.ProcessedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// Validate checks that the action carries the terms of its type and that
+// its dates are ordered ex-date, record date, pay date.
+func (action *CorporateAction) Validate() error {
+	switch action.ActionType {
+	case Split:
+		if action.SplitFrom <= 0 || action.SplitTo <= 0 || action.SplitFrom == action.SplitTo || action.DividendPerShare != nil {
+			return fmt.Errorf("%w: a split needs two different positive share counts", ErrInvalidAction)
+		}
+	case Dividend:
+		perShare := action.DividendPerShare
+		if perShare == nil || !perShare.IsPositive() || !perShare.Equal(perShare.Round(dividendPlaces, decimal.Down)) || action.SplitFrom != 0 || action.SplitTo != 0 {
+			return fmt.Errorf("%w: a dividend needs a positive amount per share with at most %d decimal places", ErrInvalidAction, dividendPlaces)
+		}
+	default:
+		return fmt.Errorf("%w: action_type must be SPLIT or DIVIDEND", ErrInvalidAction)
+	}
+
+	for _, date := range []string{action.ExDate, action.RecordDate, action.PayDate} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("%w: invalid date %q", ErrInvalidAction, date)
+		}
+	}
+	if action.RecordDate < action.ExDate || action.PayDate < action.RecordDate {
+		return fmt.Errorf("%w: dates must be ordered ex_date <= record_date <= pay_date", ErrInvalidAction)
+	}
+	return nil
+}
+
+// AnnounceCorporateAction schedules action. Its ex-date must lie after the
+// current exchange date so that it takes effect before an open. The action
+// is updated in place with its ID and status.
+func AnnounceCorporateAction(q Querier, action *CorporateAction, now time.Time) error {
+	if err := action.Validate(); err != nil {
+		return err
+	}
+	calendar, err := LoadCalendar(q)
+	if err != nil {
+		return err
+	}
+	if action.ExDate <= calendar.Date(now) {
+		return fmt.Errorf("%w: ex_date must be after today", ErrInvalidAction)
+	}
+
+	var id int
+	err = q.QueryRow(`SELECT id FROM stocks WHERE ticker = $1`, action.Ticker).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrStockNotFound
+	} else if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO corporate_actions (ticker, action_type, split_from, split_to, dividend_per_share, ex_date, record_date, pay_date, announced_by)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), $5, $6, $7, $8, $9)
+		RETURNING ` + actionColumns
+	announced, err := scanAction(q.QueryRow(query, action.Ticker, action.ActionType, action.SplitFrom, action.SplitTo,
+		action.DividendPerShare, action.ExDate, action.RecordDate, action.PayDate, action.AnnouncedBy))
+	if err != nil {
+		return err
+	}
+	*action = *announced
+	return nil
+}
+
+// CorporateActions returns the actions announced for ticker, latest ex-date
+// first.
+func CorporateActions(q Querier, ticker string) ([]CorporateAction, error) {
+	rows, err := q.Query(`SELECT `+actionColumns+` FROM corporate_actions WHERE ticker = $1 ORDER BY ex_date DESC, id DESC`, ticker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []CorporateAction{}
+	for rows.Next() {
+		action, err := scanAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, *action)
+	}
+
+	return actions, rows.Err()
+}
+
+// CancelCorporateAction withdraws an action that has not taken effect yet.
+func CancelCorporateAction(q Querier, id int) (*CorporateAction, error) {
+	query := `
+		UPDATE corporate_actions SET status = $1
+		WHERE id = $2 AND status = $3
+		RETURNING ` + actionColumns
+	action, err := scanAction(q.QueryRow(query, ActionCancelled, id, ActionScheduled))
+	if err != sql.ErrNoRows {
+		return action, err
+	}
+
+	err = q.QueryRow(`SELECT id FROM corporate_actions WHERE id = $1`, id).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrActionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return nil, ErrActionClosed
+}
+
+// ProcessCorporateActions applies every scheduled split whose ex-date has
+// been reached, records the entitlements of every scheduled dividend whose
+// record date has been reached and pays every recorded dividend whose pay
+// date has been reached, each in its own transaction. It returns how many
+// actions advanced.
+func ProcessCorporateActions(db *sql.DB, now time.Time) (int, error) {
+	calendar, err := LoadCalendar(db)
+	if err != nil {
+		return 0, err
+	}
+	today := calendar.Date(now)
+
+	query := `
+		SELECT id FROM corporate_actions
+		WHERE (status = $1 AND action_type = $4 AND ex_date <= $3)
+			OR (status = $1 AND action_type = $5 AND record_date <= $3)
+			OR (status = $2 AND pay_date <= $3)
+		ORDER BY ex_date, id`
+	rows, err := db.Query(query, ActionScheduled, ActionRecorded, today, Split, Dividend)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, id := range ids {
+		err := RunInTx(db, func(tx *sql.Tx) error {
+			return processAction(tx, id, today)
+		})
+		if err != nil {
+			return processed, fmt.Errorf("corporate action %d: %w", id, err)
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// RunCorporateActions calls ProcessCorporateActions every interval until the
+// process exits.
+func RunCorporateActions(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		processed, err := ProcessCorporateActions(db, now)
+		if err != nil {
+			log.Printf("Failed to process corporate actions: %v", err)
+			continue
+		}
+		if processed > 0 {
+			log.Printf("Processed %d corporate actions", processed)
+		}
+	}
+}
+
+// processAction moves the action through every step that is due on today.
+func processAction(tx *sql.Tx, id int, today string) error {
+	action, err := scanAction(tx.QueryRow(`SELECT `+actionColumns+` FROM corporate_actions WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return err
+	}
+
+	if action.Status == ActionScheduled {
+		switch {
+		case action.ActionType == Split && action.ExDate <= today:
+			err = applySplit(tx, action)
+		case action.ActionType == Dividend && action.RecordDate <= today:
+			err = recordEntitlements(tx, action)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if action.Status == ActionRecorded && action.PayDate <= today {
+		if err := payDividend(tx, action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (action *CorporateAction) setStatus(tx *sql.Tx, status string) error {
+	query := `UPDATE corporate_actions SET status = $1, processed_at = now() WHERE id = $2 RETURNING processed_at`
+	if err := tx.QueryRow(query, status, action.ID).Scan(&action.ProcessedAt); err != nil {
+		return err
+	}
+	action.Status = status
+	emit(tx, events.Event{Type: events.CorporateAction, Ticker: action.Ticker, Data: action})
+	return nil
+}
+
+// reference ties ledger entries to the action that caused them.
+func (action *CorporateAction) reference() string {
+	return fmt.Sprintf("corporate_actions:%d", action.ID)
+}
+
+// splitQuantity returns the whole number of shares quantity becomes after
+// the split, and the fraction of a share left over as a numerator over
+// SplitFrom. Both carry the sign of quantity.
+func (action *CorporateAction) splitQuantity(quantity int) (int, int) {
+	return quantity * action.SplitTo / action.SplitFrom, quantity * action.SplitTo % action.SplitFrom
+}
+
+// splitPrice returns price per pre-split share restated per post-split
+// share, rounded to the cent with mode.
+func (action *CorporateAction) splitPrice(price decimal.Decimal, mode decimal.RoundingMode) decimal.Decimal {
+	return price.MulInt(action.SplitFrom).DivInt(action.SplitTo, mode).Round(decimal.Cents, mode)
+}
+
+// splitCost restates a cost per pre-split share per post-split share,
+// rounded half to even to the places positions and tax lots keep.
+func (action *CorporateAction) splitCost(cost decimal.Decimal) decimal.Decimal {
+	return cost.MulInt(action.SplitFrom).DivInt(action.SplitTo, decimal.HalfEven).Round(costPlaces, decimal.HalfEven)
+}
+
+// applySplit restates the ticker in post-split shares. Positions and their
+// tax lots are scaled and their costs divided accordingly; fractional shares
+// are cashed out at the adjusted last price, paid to long holders and
+// charged to short ones. The filled and open parts of every resting order
+// are each scaled down to whole shares, and its limit and stop prices are
+// rounded so that the order never becomes more aggressive; orders left with
+// no open shares are cancelled, keeping their original quantities if no
+// whole share of them is left at all. The last, reference and historical
+// trade prices are restated too.
+func applySplit(tx *sql.Tx, action *CorporateAction) error {
+	lastPrice, err := lockStock(tx, action.Ticker)
+	if err != nil {
+		return err
+	}
+	currency, rate, err := TradingCurrency(tx, action.Ticker)
+	if err != nil {
+		return err
+	}
+	bands, err := LoadBands(tx, action.Ticker)
+	if err != nil {
+		return err
+	}
+
+	price := action.splitPrice(lastPrice, decimal.HalfEven)
+	_, err = tx.Exec(`UPDATE stocks SET price = $1, reference_price = $2 WHERE ticker = $3`,
+		price, action.splitPrice(bands.ReferencePrice, decimal.HalfEven), action.Ticker)
+	if err != nil {
+		return err
+	}
+
+	// Earlier adjustments are restated before the fractional shares of this
+	// split are recorded in post-split shares.
+	_, err = tx.Exec(`UPDATE share_adjustments SET split_factor = split_factor * $1 / $2 WHERE ticker = $3`, action.SplitTo, action.SplitFrom, action.Ticker)
+	if err != nil {
+		return err
+	}
+
+	type position struct {
+		userID      int
+		quantity    int
+		averageCost decimal.Decimal
+	}
+	rows, err := tx.Query(`SELECT user_id, quantity, average_cost FROM positions WHERE ticker = $1 AND quantity <> 0 FOR UPDATE`, action.Ticker)
+	if err != nil {
+		return err
+	}
+	var positions []position
+	for rows.Next() {
+		var p position
+		if err := rows.Scan(&p.userID, &p.quantity, &p.averageCost); err != nil {
+			rows.Close()
+			return err
+		}
+		positions = append(positions, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range positions {
+		quantity, fraction := action.splitQuantity(p.quantity)
+		averageCost := decimal.Zero
+		if quantity != 0 {
+			averageCost = action.splitCost(p.averageCost)
+		}
+		_, err := tx.Exec(`UPDATE positions SET quantity = $1, average_cost = $2 WHERE user_id = $3 AND ticker = $4`,
+			quantity, averageCost, p.userID, action.Ticker)
+		if err != nil {
+			return err
+		}
+		if err := splitLots(tx, action, p.userID, quantity); err != nil {
+			return err
+		}
+
+		if fraction != 0 {
+			_, err := tx.Exec(`INSERT INTO share_adjustments (user_id, ticker, quantity, reason) VALUES ($1, $2, -$3::numeric / $4, 'CASH_IN_LIEU')`,
+				p.userID, action.Ticker, fraction, action.SplitFrom)
+			if err != nil {
+				return err
+			}
+		}
+
+		cashInLieu := price.MulInt(fraction).DivInt(action.SplitFrom, decimal.Down).Round(decimal.Cents, decimal.Down)
+		description := fmt.Sprintf("Cash in lieu of fractional %s shares", action.Ticker)
+		if err := payHolder(tx, p.userID, currency, rate, cashInLieu, ledger.EntryCashInLieu, description, action.reference()); err != nil {
+			return err
+		}
+	}
+
+	rows, err = tx.Query(`SELECT `+orderColumns+` FROM orders WHERE ticker = $1 AND status IN ('NEW', 'PARTIALLY_FILLED') FOR UPDATE`, action.Ticker)
+	if err != nil {
+		return err
+	}
+	var orders []*models.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		orders = append(orders, order)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		filled, _ := action.splitQuantity(order.FilledQuantity)
+		remaining, _ := action.splitQuantity(order.Remaining())
+		if remaining == 0 {
+			if filled > 0 {
+				_, err := tx.Exec(`UPDATE orders SET quantity = $1, filled_quantity = $1 WHERE id = $2`, filled, order.ID)
+				if err != nil {
+					return err
+				}
+				order.Quantity, order.FilledQuantity = filled, filled
+			}
+			if err := cancel(tx, order); err != nil {
+				return err
+			}
+			continue
+		}
+
+		limitMode, stopMode := decimal.Down, decimal.Up
+		if order.Side == Sell {
+			limitMode, stopMode = decimal.Up, decimal.Down
+		}
+		price, stopPrice := action.orderPrice(order.Price, limitMode), action.orderPrice(order.StopPrice, stopMode)
+		_, err := tx.Exec(`UPDATE orders SET quantity = $1, filled_quantity = $2, price = $3, stop_price = $4, updated_at = now() WHERE id = $5`,
+			filled+remaining, filled, price, stopPrice, order.ID)
+		if err != nil {
+			return err
+		}
+		order.Quantity, order.FilledQuantity = filled+remaining, filled
+		if price != nil {
+			order.Price = *price
+		}
+		if stopPrice != nil {
+			order.StopPrice = *stopPrice
+		}
+		if err := recordOrder(tx, order); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE transactions SET split_factor = split_factor * $1 / $2 WHERE ticker = $3`, action.SplitTo, action.SplitFrom, action.Ticker)
+	if err != nil {
+		return err
+	}
+	// The unsettled shares of every position are restated with the trades
+	// they come from, so that settling each trade releases its own shares.
+	query := `
+		UPDATE positions p SET unsettled_quantity = COALESCE((
+			SELECT SUM(TRUNC(s.quantity * t.split_factor))
+			FROM settlements s
+			INNER JOIN transactions t ON t.id = s.transaction_id
+			WHERE s.user_id = p.user_id AND s.ticker = p.ticker AND s.status = $1
+		), 0)
+		WHERE p.ticker = $2`
+	if _, err := tx.Exec(query, SettlementPending, action.Ticker); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE price_history SET split_factor = split_factor * $1 / $2 WHERE ticker = $3`, action.SplitTo, action.SplitFrom, action.Ticker)
+	if err != nil {
+		return err
+	}
+	if err := emitBook(tx, action.Ticker); err != nil {
+		return err
+	}
+
+	return action.setStatus(tx, ActionCompleted)
+}
+
+// orderPrice restates an order price for the split, keeping it at least one
+// cent. Unset prices stay unset.
+func (action *CorporateAction) orderPrice(price decimal.Decimal, mode decimal.RoundingMode) *decimal.Decimal {
+	if !price.IsPositive() {
+		return nil
+	}
+	return nullPrice(decimal.Max(action.splitPrice(price, mode), decimal.New(1, -decimal.Cents)))
+}
+
+// recordEntitlements records the dividend due on every position in the
+// ticker settled by the end of the record date: long holders receive it and
+// short holders owe it. Amounts are rounded down to the cent.
+func recordEntitlements(tx *sql.Tx, action *CorporateAction) error {
+	if _, err := lockStock(tx, action.Ticker); err != nil {
+		return err
+	}
+
+	holdings, err := SettledHoldings(tx, action.Ticker, action.RecordDate)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range holdings {
+		amount := action.DividendPerShare.MulInt(abs(h.Quantity)).Round(decimal.Cents, decimal.Down)
+		if h.Quantity < 0 {
+			amount = amount.Neg()
+		}
+		_, err := tx.Exec(`INSERT INTO dividend_entitlements (action_id, user_id, quantity, amount) VALUES ($1, $2, $3, $4)`,
+			action.ID, h.UserID, h.Quantity, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return action.setStatus(tx, ActionRecorded)
+}
+
+// payDividend books every recorded entitlement of the dividend.
+func payDividend(tx *sql.Tx, action *CorporateAction) error {
+	currency, rate, err := TradingCurrency(tx, action.Ticker)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT user_id, quantity, amount FROM dividend_entitlements WHERE action_id = $1 AND paid_at IS NULL`, action.ID)
+	if err != nil {
+		return err
+	}
+	type entitlement struct {
+		userID   int
+		quantity int
+		amount   decimal.Decimal
+	}
+	var entitlements []entitlement
+	for rows.Next() {
+		var e entitlement
+		if err := rows.Scan(&e.userID, &e.quantity, &e.amount); err != nil {
+			rows.Close()
+			return err
+		}
+		entitlements = append(entitlements, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range entitlements {
+		description := fmt.Sprintf("Dividend of %s per share on %d %s", action.DividendPerShare, e.quantity, action.Ticker)
+		if err := payHolder(tx, e.userID, currency, rate, e.amount, ledger.EntryDividend, description, action.reference()); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE dividend_entitlements SET paid_at = now() WHERE action_id = $1 AND paid_at IS NULL`, action.ID)
+	if err != nil {
+		return err
+	}
+
+	return action.setStatus(tx, ActionCompleted)
+}
+
+// payHolder moves amount in currency from the issuer, represented by
+// external cash, to a holder; negative amounts are charged to the holder,
+// converting from their base currency cash if needed.
+func payHolder(tx *sql.Tx, userID int, currency string, rate, amount decimal.Decimal, entryType, description, reference string) error {
+	if amount.IsZero() {
+		return nil
+	}
+	issuer, err := ledger.CurrencyAccount(tx, ledger.ExternalCashAccount, currency)
+	if err != nil {
+		return err
+	}
+	if amount.IsPositive() {
+		holder, err := ledger.CurrencyAccount(tx, ledger.UserAccount(userID), currency)
+		if err != nil {
+			return err
+		}
+		return ledger.Transfer(tx, entryType, description, reference, issuer, holder, amount)
+	}
+
+	holder, err := fund(tx, userID, currency, rate, amount.Neg(), reference)
+	if err != nil {
+		return err
+	}
+	return ledger.Transfer(tx, entryType, description, reference, holder, issuer, amount.Neg())
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// TradingCurrency returns the currency ticker trades in and its current
+// rate to the base currency.
+func TradingCurrency(q Querier, ticker string) (string, decimal.Decimal, error) {
+	var currency string
+	err := q.QueryRow(`SELECT currency FROM stocks WHERE ticker = $1`, ticker).Scan(&currency)
+	if err == sql.ErrNoRows {
+		return "", decimal.Zero, ErrStockNotFound
+	} else if err != nil {
+		return "", decimal.Zero, err
+	}
+
+	rate, err := fx.Load(q, currency)
+	if err != nil {
+		return "", decimal.Zero, err
+	}
+	return currency, rate, nil
+}
+
+// baseCost returns the base-currency cash a user has to convert to pay
+// amount in currency once the settled cash they already hold in that
+// currency is spent. Conversions are rounded up to the cent.
+func baseCost(q Querier, userID int, currency string, rate, amount decimal.Decimal) (decimal.Decimal, error) {
+	if currency == fx.Base {
+		return amount, nil
+	}
+	held, err := SettledCash(q, userID, currency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	short := amount.Sub(decimal.Max(held, decimal.Zero))
+	if !short.IsPositive() {
+		return decimal.Zero, nil
+	}
+	return fx.ToBase(short, rate, decimal.Ceiling), nil
+}
+
+// fund returns the cash account of a user in currency, first converting
+// from their base-currency cash whatever they are short of amount there.
+func fund(tx *sql.Tx, userID int, currency string, rate, amount decimal.Decimal, reference string) (string, error) {
+	account, err := ledger.CurrencyAccount(tx, ledger.UserAccount(userID), currency)
+	if err != nil || currency == fx.Base {
+		return account, err
+	}
+
+	held, err := ledger.CashBalance(tx, userID, currency)
+	if err != nil {
+		return "", err
+	}
+	short := amount.Sub(held)
+	if !short.IsPositive() {
+		return account, nil
+	}
+
+	err = ledger.Convert(tx, fmt.Sprintf("%s %s @ %s", short, currency, rate), reference, ledger.UserAccount(userID),
+		fx.Base, fx.ToBase(short, rate, decimal.Ceiling), currency, short)
+	if err != nil {
+		return "", err
+	}
+	return account, nil
+}
@@ -0,0 +1,464 @@
+// Package engine implements the per-ticker limit order book. Orders rest in
+// the orders table and are matched against the opposite side by price-time
+// priority: best price first, then earliest place in the queue. An order
+// keeps its place from arrival until an amendment forfeits it.
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	Buy  = "BUY"
+	Sell = "SELL"
+)
+
+const (
+	Market    = "MARKET"
+	Limit     = "LIMIT"
+	Stop      = "STOP"
+	StopLimit = "STOP_LIMIT"
+)
+
+const (
+	StatusNew             = "NEW"
+	StatusPartiallyFilled = "PARTIALLY_FILLED"
+	StatusFilled          = "FILLED"
+	StatusCancelled       = "CANCELLED"
+	StatusRejected        = "REJECTED"
+	StatusExpired         = "EXPIRED"
+)
+
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrStockNotFound       = errors.New("stock not found")
+	ErrInsufficientBalance = errors.New("insufficient buying power")
+	ErrInsufficientShares  = errors.New("insufficient shares")
+	ErrInvalidOrder        = errors.New("invalid order")
+)
+
+// Fill is a single execution between an incoming order and a resting order.
+// Trades always print at the resting order's price. TakerSide is the side of
+// the incoming order. Price and fees are in the ticker's Currency, which was
+// worth FXRate in the base currency when the trade printed.
+type Fill struct {
+	BuyOrderID     int             `json:"buy_order_id"`
+	SellOrderID    int             `json:"sell_order_id"`
+	BuyerID        int             `json:"buyer_id"`
+	SellerID       int             `json:"seller_id"`
+	Ticker         string          `json:"ticker"`
+	Price          decimal.Decimal `json:"price"`
+	Volume         int             `json:"volume"`
+	TakerSide      string          `json:"taker_side"`
+	BuyerFee       decimal.Decimal `json:"buyer_fee"`
+	SellerFee      decimal.Decimal `json:"seller_fee"`
+	Currency       string          `json:"currency"`
+	FXRate         decimal.Decimal `json:"fx_rate"`
+	Timestamp      time.Time       `json:"timestamp"`
+	TradeDate      string          `json:"trade_date"`
+	SettlementDate string          `json:"settlement_date"`
+}
+
+// Notional returns the cash value of the fill.
+func (fill Fill) Notional() decimal.Decimal {
+	return fill.Price.MulInt(fill.Volume)
+}
+
+// Validate checks that the prices set on order agree with its type: limit
+// orders carry a limit price, stop orders a stop price, and stop-limit
+// orders both. Prices are quoted in whole cents.
+func Validate(order *models.Order) error {
+	if order.Side != Buy && order.Side != Sell {
+		return ErrInvalidOrder
+	}
+	if order.Quantity <= 0 || order.Price.IsNegative() || order.StopPrice.IsNegative() {
+		return ErrInvalidOrder
+	}
+	if !isCents(order.Price) || !isCents(order.StopPrice) {
+		return ErrInvalidOrder
+	}
+
+	hasLimit, hasStop := order.Price.IsPositive(), order.StopPrice.IsPositive()
+	switch order.OrderType {
+	case Market:
+		if hasLimit || hasStop {
+			return ErrInvalidOrder
+		}
+	case Limit:
+		if !hasLimit || hasStop {
+			return ErrInvalidOrder
+		}
+	case Stop:
+		if hasLimit || !hasStop {
+			return ErrInvalidOrder
+		}
+	case StopLimit:
+		if !hasLimit || !hasStop {
+			return ErrInvalidOrder
+		}
+	default:
+		return ErrInvalidOrder
+	}
+
+	return nil
+}
+
+// isCents reports whether price has no digits below the cent.
+func isCents(price decimal.Decimal) bool {
+	return price.Equal(price.Round(decimal.Cents, decimal.Down))
+}
+
+// Submit places the order on the book of its ticker for the given user and
+// matches it against resting orders. Market orders trade at any price and
+// never rest; whatever cannot be filled immediately is cancelled. Stop and
+// stop-limit orders wait untriggered until the last trade price reaches
+// their stop price. The time in force decides how long the rest of the order
+// lives: IOC remainders are cancelled, FOK orders that cannot fill in full
+// are rejected, and DAY and GTD orders expire at session close or their
+// expiry. Orders are only accepted during the regular session and while the
+// ticker is not halted, and limit prices must lie inside the ticker's static
+// price band. Margin accounts in a margin call may only place orders that
+// reduce their positions. The order is updated in place with its ID, status
+// and filled quantity.
+//
+// Submit expects to run inside RunInTx. The stock row is locked for the
+// duration of tx so that matching for a single ticker is serialized, and the
+// user rows and positions of both parties are locked before their buying
+// power is checked so that concurrent orders cannot spend the same cash
+// twice.
+func Submit(tx *sql.Tx, userID int, order *models.Order) ([]Fill, error) {
+	if err := Validate(order); err != nil {
+		return nil, err
+	}
+
+	err := tx.QueryRow(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&order.UserID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	lastPrice, err := lockStock(tx, order.Ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	calendar, err := ensureTradable(tx, order.Ticker, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTimeInForce(order, now, calendar.NextClose(now)); err != nil {
+		return nil, err
+	}
+	if err := ensureInBand(tx, order); err != nil {
+		return nil, err
+	}
+
+	order.FilledQuantity = 0
+	if err := ensureFunds(tx, order, lastPrice, 0); err != nil {
+		return nil, err
+	}
+
+	order.Status = StatusNew
+	order.Triggered = !isStop(order) || stopReached(order, lastPrice)
+	query := `
+		INSERT INTO orders (user_id, ticker, side, order_type, time_in_force, price, stop_price, triggered, quantity, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at`
+	err = tx.QueryRow(query, order.UserID, order.Ticker, order.Side, order.OrderType, order.TimeInForce, nullPrice(order.Price),
+		nullPrice(order.StopPrice), order.Triggered, order.Quantity, order.Status, order.ExpiresAt).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	fills, err := execute(tx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fills) > 0 {
+		if err := triggerStops(tx, order.Ticker); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := emitBook(tx, order.Ticker); err != nil {
+		return nil, err
+	}
+
+	return fills, nil
+}
+
+// execute matches a triggered order against the book and stores its
+// resulting state. Untriggered stop orders are left untouched.
+func execute(tx *sql.Tx, order *models.Order) ([]Fill, error) {
+	if !order.Triggered {
+		return nil, recordOrder(tx, order)
+	}
+
+	// A fill-or-kill order that cannot be filled in full must leave no
+	// trace on the book, so its matching runs inside a savepoint. The
+	// events it raises, such as a circuit breaker halt, are dropped with it.
+	raised := eventCount(tx)
+	if order.TimeInForce == FOK {
+		if _, err := tx.Exec(`SAVEPOINT fill_or_kill`); err != nil {
+			return nil, err
+		}
+	}
+
+	fills, err := match(tx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.TimeInForce == FOK && order.Remaining() > 0 {
+		if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT fill_or_kill`); err != nil {
+			return nil, err
+		}
+		discard(tx, raised)
+		fills = nil
+		order.FilledQuantity = 0
+		order.Status = StatusRejected
+	}
+
+	// Orders without a limit price and IOC orders do not rest on the book.
+	if (order.Price.IsZero() || order.TimeInForce == IOC) && order.Remaining() > 0 && order.Status != StatusRejected {
+		order.Status = StatusCancelled
+	}
+
+	_, err = tx.Exec(`UPDATE orders SET filled_quantity = $1, status = $2, triggered = $3, updated_at = now() WHERE id = $4`,
+		order.FilledQuantity, order.Status, order.Triggered, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordOrder(tx, order); err != nil {
+		return nil, err
+	}
+
+	emitTrades(tx, fills)
+	return fills, nil
+}
+
+// match crosses order against the opposite side of the book until it is
+// filled, no resting order is priced to trade with it, its owner can no
+// longer pay for the next fill, the next trade would print outside the
+// ticker's price bands, or a trade trips the circuit breaker.
+func match(tx *sql.Tx, order *models.Order) ([]Fill, error) {
+	var fills []Fill
+
+	lastPrice, err := lockStock(tx, order.Ticker)
+	if err != nil {
+		return nil, err
+	}
+	bands, err := LoadBands(tx, order.Ticker)
+	if err != nil {
+		return nil, err
+	}
+	currency, rate, err := TradingCurrency(tx, order.Ticker)
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := LoadCalendar(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for order.Remaining() > 0 {
+		resting, err := bestOpposite(tx, order)
+		if err == sql.ErrNoRows {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		// The book is sorted best price first, so once the best resting
+		// price is outside the bands every other one is too.
+		if !bands.WithinStatic(resting.Price) || !bands.WithinDynamic(lastPrice, resting.Price) {
+			break
+		}
+
+		fill := Fill{
+			Ticker:    order.Ticker,
+			Price:     resting.Price,
+			Volume:    min(order.Remaining(), resting.Remaining()),
+			TakerSide: order.Side,
+			Currency:  currency,
+			FXRate:    rate,
+			Timestamp: time.Now(),
+		}
+		fill.TradeDate = calendar.Date(fill.Timestamp)
+		fill.SettlementDate = calendar.SettlementDate(fill.Timestamp)
+		if order.Side == Buy {
+			fill.BuyOrderID, fill.BuyerID = order.ID, order.UserID
+			fill.SellOrderID, fill.SellerID = resting.ID, resting.UserID
+		} else {
+			fill.BuyOrderID, fill.BuyerID = resting.ID, resting.UserID
+			fill.SellOrderID, fill.SellerID = order.ID, order.UserID
+		}
+		if err := chargeFees(tx, &fill); err != nil {
+			return nil, err
+		}
+
+		ok, err := canSettle(tx, order, fill)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		// The resting party may no longer be able to settle: a buyer may
+		// have spent their cash since the order was accepted. Such orders
+		// are pulled from the book rather than filled.
+		ok, err = canSettle(tx, resting, fill)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if err := cancel(tx, resting); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := settle(tx, fill); err != nil {
+			return nil, err
+		}
+
+		applyFill(order, fill.Volume)
+		applyFill(resting, fill.Volume)
+		_, err = tx.Exec(`UPDATE orders SET filled_quantity = $1, status = $2, updated_at = now() WHERE id = $3`, resting.FilledQuantity, resting.Status, resting.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordOrder(tx, resting); err != nil {
+			return nil, err
+		}
+
+		fills = append(fills, fill)
+		lastPrice = fill.Price
+
+		tripped, err := tripCircuitBreaker(tx, order.Ticker, bands, fill.Price, fill.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if tripped {
+			break
+		}
+	}
+
+	return fills, nil
+}
+
+// lockStock locks the stock row of ticker and returns its last trade price.
+func lockStock(tx *sql.Tx, ticker string) (decimal.Decimal, error) {
+	var lastPrice decimal.Decimal
+	err := tx.QueryRow(`SELECT price FROM stocks WHERE ticker = $1 FOR UPDATE`, ticker).Scan(&lastPrice)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, ErrStockNotFound
+	}
+	return lastPrice, err
+}
+
+// settle moves cash and shares between the two parties of fill, charges
+// both their commissions to the exchange's fee revenue, records a
+// transaction row for each side, with the P&L the fill realized for it,
+// books each side's settlement obligation, adds the fill to both parties'
+// feeds and updates the ticker's last traded price and price history.
+// Cash moves through two journal entries: the trade itself and the
+// commissions. Both are in the ticker's currency; a party short of cash in
+// that currency first has the difference converted from their base currency
+// cash at the fill's rate. The seller's proceeds are booked to their
+// unsettled account until the trade settles.
+func settle(tx *sql.Tx, fill Fill) error {
+	notional := fill.Notional()
+	reference := fmt.Sprintf("orders:%d,%d", fill.BuyOrderID, fill.SellOrderID)
+
+	buyer, err := fund(tx, fill.BuyerID, fill.Currency, fill.FXRate, notional.Add(fill.BuyerFee), reference)
+	if err != nil {
+		return err
+	}
+	seller, err := fund(tx, fill.SellerID, fill.Currency, fill.FXRate, fill.SellerFee.Sub(notional), reference)
+	if err != nil {
+		return err
+	}
+	seller, err = proceedsAccount(tx, fill, fill.SellerID, seller, notional.Sub(fill.SellerFee))
+	if err != nil {
+		return err
+	}
+	feeRevenue, err := ledger.CurrencyAccount(tx, ledger.FeeRevenueAccount, fill.Currency)
+	if err != nil {
+		return err
+	}
+
+	err = ledger.Transfer(tx, ledger.EntryTrade, fmt.Sprintf("%d %s @ %s", fill.Volume, fill.Ticker, fill.Price),
+		reference, buyer, seller, notional)
+	if err != nil {
+		return err
+	}
+	err = ledger.Post(tx, &ledger.Entry{
+		Type:        ledger.EntryFee,
+		Description: fmt.Sprintf("Commission on %d %s", fill.Volume, fill.Ticker),
+		Reference:   reference,
+		Postings: []ledger.Posting{
+			{Account: buyer, Amount: fill.BuyerFee.Neg()},
+			{Account: seller, Amount: fill.SellerFee.Neg()},
+			{Account: feeRevenue, Amount: fill.BuyerFee.Add(fill.SellerFee)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	buyerPnL, err := UpdatePosition(tx, fill.BuyerID, fill.Ticker, fill.Volume, fill.Price)
+	if err != nil {
+		return err
+	}
+	sellerPnL, err := UpdatePosition(tx, fill.SellerID, fill.Ticker, -fill.Volume, fill.Price)
+	if err != nil {
+		return err
+	}
+
+	insertQuery := `
+		INSERT INTO transactions (user_id, ticker, transaction_type, transaction_volume, transaction_price, timestamp, order_id, fee, liquidity, currency, fx_rate, realized_pnl)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id`
+	var buyID, sellID int
+	err = tx.QueryRow(insertQuery, fill.BuyerID, fill.Ticker, Buy, fill.Volume, notional, fill.Timestamp, fill.BuyOrderID,
+		fill.BuyerFee, fill.liquidity(Buy), fill.Currency, fill.FXRate, buyerPnL).Scan(&buyID)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow(insertQuery, fill.SellerID, fill.Ticker, Sell, fill.Volume, notional, fill.Timestamp, fill.SellOrderID,
+		fill.SellerFee, fill.liquidity(Sell), fill.Currency, fill.FXRate, sellerPnL).Scan(&sellID)
+	if err != nil {
+		return err
+	}
+
+	if err := recordSettlement(tx, fill, buyID, fill.BuyerID, fill.Volume, notional.Add(fill.BuyerFee).Neg()); err != nil {
+		return err
+	}
+	if err := recordSettlement(tx, fill, sellID, fill.SellerID, -fill.Volume, notional.Sub(fill.SellerFee)); err != nil {
+		return err
+	}
+	if err := recordFill(tx, fill, Buy, buyID, buyerPnL); err != nil {
+		return err
+	}
+	if err := recordFill(tx, fill, Sell, sellID, sellerPnL); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE stocks SET price = $1 WHERE ticker = $2`, fill.Price, fill.Ticker)
+	if err != nil {
+		return err
+	}
+	return recordPrice(tx, fill.Ticker, fill.Price, fill.Volume, fill.Timestamp)
+}
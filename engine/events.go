@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"database/sql"
+	"sync"
+
+	"stock_exchange_Golang_project/events"
+)
+
+// pending holds the events raised inside each open transaction. They are
+// only published once the transaction commits, so subscribers never see
+// activity that was rolled back.
+var pending sync.Map
+
+func emit(tx *sql.Tx, event events.Event) {
+	queued, _ := pending.LoadOrStore(tx, &[]events.Event{})
+	list := queued.(*[]events.Event)
+	*list = append(*list, event)
+}
+
+// flush publishes the events raised inside tx when committed is true and
+// discards them otherwise.
+func flush(tx *sql.Tx, committed bool) {
+	queued, ok := pending.LoadAndDelete(tx)
+	if !ok || !committed {
+		return
+	}
+	for _, event := range *queued.(*[]events.Event) {
+		events.Publish(event)
+	}
+}
+
+// eventCount returns how many events tx has raised so far, so that the
+// events raised after a savepoint can be dropped with discard.
+func eventCount(tx *sql.Tx) int {
+	queued, ok := pending.Load(tx)
+	if !ok {
+		return 0
+	}
+	return len(*queued.(*[]events.Event))
+}
+
+// discard drops the events tx raised after the first n, for when the
+// savepoint they were raised under is rolled back.
+func discard(tx *sql.Tx, n int) {
+	queued, ok := pending.Load(tx)
+	if !ok {
+		return
+	}
+	list := queued.(*[]events.Event)
+	if n < len(*list) {
+		*list = (*list)[:n]
+	}
+}
@@ -0,0 +1,28 @@
+package engine
+
+import (
+	"database/sql"
+	"testing"
+
+	"stock_exchange_Golang_project/events"
+)
+
+// TestDiscardDropsEventsAfterSavepoint checks that rolling back to a
+// savepoint drops the events raised after it, like the halt of a circuit
+// breaker tripped by a fill-or-kill order that is then rejected, and keeps
+// those raised before it.
+func TestDiscardDropsEventsAfterSavepoint(t *testing.T) {
+	tx := &sql.Tx{}
+	defer pending.Delete(tx)
+
+	emit(tx, events.Event{Type: events.Trade, Ticker: "AAPL"})
+	raised := eventCount(tx)
+	emit(tx, events.Event{Type: events.Halt, Ticker: "AAPL"})
+	discard(tx, raised)
+
+	queued, _ := pending.Load(tx)
+	list := *queued.(*[]events.Event)
+	if len(list) != 1 || list[0].Type != events.Trade {
+		t.Fatalf("queued events = %+v, want only the trade raised before the savepoint", list)
+	}
+}
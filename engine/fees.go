@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"database/sql"
+
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	Maker = "MAKER"
+	Taker = "TAKER"
+)
+
+// Commission returns the fee charged to a user for a fill of volume shares
+// worth notional, where liquidity says whether the user's order was resting
+// on the book (maker) or took liquidity from it (taker). The schedule tier
+// is the one with the highest volume threshold the user has already traded
+// this calendar month. Fees are the sum of the tier's per-share, percentage
+// and flat components, rounded half up to the cent.
+func Commission(tx *sql.Tx, userID int, liquidity string, volume int, notional decimal.Decimal) (decimal.Decimal, error) {
+	var monthlyVolume int
+	query := `
+		SELECT COALESCE(SUM(transaction_volume), 0)
+		FROM transactions
+		WHERE user_id = $1 AND timestamp >= date_trunc('month', now())`
+	if err := tx.QueryRow(query, userID).Scan(&monthlyVolume); err != nil {
+		return decimal.Zero, err
+	}
+
+	var perShare, percentage, flat decimal.Decimal
+	query = `
+		SELECT per_share, percentage, flat
+		FROM fee_schedules
+		WHERE liquidity = $1 AND min_monthly_volume <= $2
+		ORDER BY min_monthly_volume DESC
+		LIMIT 1`
+	err := tx.QueryRow(query, liquidity, monthlyVolume).Scan(&perShare, &percentage, &flat)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, nil
+	} else if err != nil {
+		return decimal.Zero, err
+	}
+
+	fee := perShare.MulInt(volume).Add(percentage.Mul(notional)).Add(flat)
+	return fee.Round(decimal.Cents, decimal.HalfUp), nil
+}
+
+// chargeFees prices the commission of both parties to fill. The owner of
+// the incoming order is the taker.
+func chargeFees(tx *sql.Tx, fill *Fill) error {
+	buyerLiquidity, sellerLiquidity := Taker, Maker
+	if fill.TakerSide == Sell {
+		buyerLiquidity, sellerLiquidity = Maker, Taker
+	}
+
+	var err error
+	fill.BuyerFee, err = Commission(tx, fill.BuyerID, buyerLiquidity, fill.Volume, fill.Notional())
+	if err != nil {
+		return err
+	}
+	fill.SellerFee, err = Commission(tx, fill.SellerID, sellerLiquidity, fill.Volume, fill.Notional())
+	return err
+}
+
+// liquidity returns whether the party on side of fill was maker or taker.
+func (fill Fill) liquidity(side string) string {
+	if side == fill.TakerSide {
+		return Taker
+	}
+	return Maker
+}
+
+// fee returns the commission charged to the party on side of fill.
+func (fill Fill) fee(side string) decimal.Decimal {
+	if side == Buy {
+		return fill.BuyerFee
+	}
+	return fill.SellerFee
+}
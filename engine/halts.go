@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Halt is a suspension of trading in one ticker or, when Ticker is empty,
+// on the whole exchange. A halt with ResumeAt set lifts itself at that time.
+type Halt struct {
+	ID        int        `json:"id"`
+	Ticker    string     `json:"ticker"`
+	Reason    string     `json:"reason"`
+	HaltedBy  string     `json:"halted_by"`
+	HaltedAt  time.Time  `json:"halted_at"`
+	ResumeAt  *time.Time `json:"resume_at,omitempty"`
+	ResumedAt *time.Time `json:"resumed_at,omitempty"`
+}
+
+// activeHalt matches halts that have been neither resumed nor lifted by
+// reaching their resume time.
+const activeHalt = `resumed_at IS NULL AND (resume_at IS NULL OR resume_at > now())`
+
+// HaltTrading suspends trading in ticker, or on the whole exchange when
+// ticker is empty.
+func HaltTrading(q Querier, ticker, reason, haltedBy string, resumeAt *time.Time) (*Halt, error) {
+	halt := Halt{Ticker: ticker, Reason: reason, HaltedBy: haltedBy, ResumeAt: resumeAt}
+	query := `
+		INSERT INTO trading_halts (ticker, reason, halted_by, resume_at)
+		VALUES (NULLIF($1, ''), $2, $3, $4)
+		RETURNING id, halted_at`
+	err := q.QueryRow(query, ticker, reason, haltedBy, resumeAt).Scan(&halt.ID, &halt.HaltedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &halt, nil
+}
+
+// ResumeTrading lifts the active halts on ticker, or the exchange-wide halts
+// when ticker is empty, and returns how many were lifted.
+func ResumeTrading(db *sql.DB, ticker string) (int64, error) {
+	query := `
+		UPDATE trading_halts SET resumed_at = now()
+		WHERE COALESCE(ticker, '') = $1 AND ` + activeHalt
+	result, err := db.Exec(query, ticker)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ActiveHalts returns every halt currently in force, oldest first.
+func ActiveHalts(q Querier) ([]Halt, error) {
+	query := `
+		SELECT id, COALESCE(ticker, ''), reason, halted_by, halted_at, resume_at, resumed_at
+		FROM trading_halts
+		WHERE ` + activeHalt + `
+		ORDER BY halted_at`
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	halts := []Halt{}
+	for rows.Next() {
+		var halt Halt
+		err := rows.Scan(&halt.ID, &halt.Ticker, &halt.Reason, &halt.HaltedBy, &halt.HaltedAt, &halt.ResumeAt, &halt.ResumedAt)
+		if err != nil {
+			return nil, err
+		}
+		halts = append(halts, halt)
+	}
+
+	return halts, rows.Err()
+}
+
+// IsHalted reports whether trading in ticker is suspended, either by its own
+// halt or by an exchange-wide one.
+func IsHalted(q Querier, ticker string) (bool, error) {
+	var halted bool
+	query := `SELECT EXISTS (SELECT 1 FROM trading_halts WHERE (ticker IS NULL OR ticker = $1) AND ` + activeHalt + `)`
+	err := q.QueryRow(query, ticker).Scan(&halted)
+	return halted, err
+}
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+var ErrInvalidInterval = errors.New("interval must be 1m, 1h or 1d")
+
+// maxCandles bounds the number of bars one request may cover.
+const maxCandles = 5000
+
+// candleIntervals maps the supported bar sizes to their length and the
+// date_trunc field that buckets a timestamp into them.
+var candleIntervals = map[string]struct {
+	length time.Duration
+	field  string
+}{
+	"1m": {time.Minute, "minute"},
+	"1h": {time.Hour, "hour"},
+	"1d": {24 * time.Hour, "day"},
+}
+
+// Candle is one OHLCV bar. Prices and volume are restated in current shares
+// when the ticker has split since, like transactions.
+type Candle struct {
+	Time   time.Time       `json:"time"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume decimal.Decimal `json:"volume"`
+	Trades int             `json:"trades"`
+}
+
+// recordPrice appends a price of ticker to its history. volume is the
+// number of shares traded at price, or zero for a price set without a
+// trade such as a listing.
+func recordPrice(tx *sql.Tx, ticker string, price decimal.Decimal, volume int, at time.Time) error {
+	_, err := tx.Exec(`INSERT INTO price_history (ticker, price, volume, recorded_at) VALUES ($1, $2, $3, $4)`,
+		ticker, price, volume, at)
+	return err
+}
+
+// ListStock records the listing price of a newly created ticker as the
+// first entry of its price history.
+func ListStock(tx *sql.Tx, ticker string, price decimal.Decimal) error {
+	return recordPrice(tx, ticker, price, 0, time.Now())
+}
+
+// SetMarketPrice makes price, observed by an outside market data source,
+// the last price of ticker and adds it to the price history at at with the
+// volume the source reported. The price is subject to the price bands and
+// circuit breaker of the ticker like a trade.
+func SetMarketPrice(tx *sql.Tx, ticker string, price decimal.Decimal, volume int, at time.Time) error {
+	current, err := lockListedStock(tx, ticker)
+	if err != nil {
+		return err
+	}
+	if !price.IsPositive() || !isCents(price) {
+		return ErrInvalidStock
+	}
+	return movePrice(tx, ticker, current.price, price, volume, at)
+}
+
+// movePrice makes price the last price of ticker, whose row tx has locked,
+// and adds it to the price history. Like a trade, the price must lie inside
+// the static band and the dynamic band around lastPrice. While the ticker is
+// tradable a move that trips the circuit breaker halts it, and any other
+// move triggers the stop orders it reaches; when the market is closed or the
+// ticker halted the stops wait for the next trade.
+func movePrice(tx *sql.Tx, ticker string, lastPrice, price decimal.Decimal, volume int, at time.Time) error {
+	bands, err := LoadBands(tx, ticker)
+	if err != nil {
+		return err
+	}
+	if !bands.WithinStatic(price) || !bands.WithinDynamic(lastPrice, price) {
+		return ErrPriceOutOfBand
+	}
+
+	if _, err := tx.Exec(`UPDATE stocks SET price = $1 WHERE ticker = $2`, price, ticker); err != nil {
+		return err
+	}
+	if err := recordPrice(tx, ticker, price, volume, at); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = ensureTradable(tx, ticker, now)
+	switch {
+	case err == nil:
+		tripped, err := tripCircuitBreaker(tx, ticker, bands, price, now)
+		if err != nil {
+			return err
+		}
+		if !tripped {
+			if err := triggerStops(tx, ticker); err != nil {
+				return err
+			}
+		}
+	case !errors.Is(err, ErrMarketClosed) && !errors.Is(err, ErrTradingHalted):
+		return err
+	}
+
+	return emitBook(tx, ticker)
+}
+
+// CandleRange returns the default time range of a candles request for
+// interval ending at to: the last 500 bars.
+func CandleRange(interval string, to time.Time) (time.Time, error) {
+	size, ok := candleIntervals[interval]
+	if !ok {
+		return time.Time{}, ErrInvalidInterval
+	}
+	return to.Add(-500 * size.length), nil
+}
+
+// Candles returns the OHLCV bars of ticker for interval, oldest first, over
+// the prices recorded from from up to but excluding to. Bars without any
+// recorded price are left out. Daily bars follow calendar days in the
+// exchange timezone; a range covering more than maxCandles bars is
+// rejected.
+func Candles(q Querier, ticker, interval string, from, to time.Time) ([]Candle, error) {
+	size, ok := candleIntervals[interval]
+	if !ok || !from.Before(to) || to.Sub(from) > maxCandles*size.length {
+		return nil, ErrInvalidInterval
+	}
+
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM stocks WHERE ticker = $1)`, ticker).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrStockNotFound
+	}
+	calendar, err := LoadCalendar(q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restated prices and volumes are rounded to the places Decimal holds;
+	// prices are then rounded to the cent by roundPrices.
+	query := `
+		WITH restated AS (
+			SELECT id, recorded_at, volume, ROUND(price / split_factor, 8) AS price, ROUND(volume * split_factor, 8) AS shares
+			FROM price_history
+			WHERE ticker = $1 AND recorded_at >= $4 AND recorded_at < $5
+		)
+		SELECT date_trunc($2, recorded_at AT TIME ZONE $3) AT TIME ZONE $3 AS bucket,
+			(array_agg(price ORDER BY recorded_at, id))[1],
+			MAX(price),
+			MIN(price),
+			(array_agg(price ORDER BY recorded_at DESC, id DESC))[1],
+			SUM(shares),
+			COUNT(*) FILTER (WHERE volume > 0)
+		FROM restated
+		GROUP BY bucket
+		ORDER BY bucket`
+	rows, err := q.Query(query, ticker, size.field, calendar.Timezone, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candles := []Candle{}
+	for rows.Next() {
+		var candle Candle
+		err := rows.Scan(&candle.Time, &candle.Open, &candle.High, &candle.Low, &candle.Close, &candle.Volume, &candle.Trades)
+		if err != nil {
+			return nil, err
+		}
+		candle.roundPrices()
+		candles = append(candles, candle)
+	}
+	return candles, rows.Err()
+}
+
+// roundPrices rounds the restated prices of c to the nearest cent, with
+// half-cent ties going to the even cent, so that restating a series of
+// prices does not drift them up or down.
+func (c *Candle) roundPrices() {
+	c.Open = c.Open.Round(decimal.Cents, decimal.HalfEven)
+	c.High = c.High.Round(decimal.Cents, decimal.HalfEven)
+	c.Low = c.Low.Round(decimal.Cents, decimal.HalfEven)
+	c.Close = c.Close.Round(decimal.Cents, decimal.HalfEven)
+}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"testing"
+
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+func TestCandleRoundPrices(t *testing.T) {
+	tests := []struct {
+		restated, want string
+	}{
+		// 20.25 and 20.27 halved by a 2-for-1 split fall on half-cent ties,
+		// which go to the even cent.
+		{"10.125", "10.12"},
+		{"10.135", "10.14"},
+		{"10.12500001", "10.13"},
+		{"10.12499999", "10.12"},
+		{"10.12", "10.12"},
+	}
+	for _, tt := range tests {
+		price := decimal.MustParse(tt.restated)
+		candle := Candle{Open: price, High: price, Low: price, Close: price}
+		candle.roundPrices()
+		want := decimal.MustParse(tt.want)
+		for _, got := range []decimal.Decimal{candle.Open, candle.High, candle.Low, candle.Close} {
+			if !got.Equal(want) {
+				t.Errorf("roundPrices(%s) = %s, want %s", tt.restated, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderClosed   = errors.New("order is no longer open")
+)
+
+// Querier is satisfied by both *sql.DB and *sql.Tx.
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// OrderFilter narrows ListOrders. Empty fields match every order.
+type OrderFilter struct {
+	Ticker string
+	Status string
+}
+
+// Amendment lists the fields of an open order to replace. Nil fields are
+// left unchanged. Quantity is the new total quantity, including whatever has
+// already been filled.
+type Amendment struct {
+	Price     *decimal.Decimal
+	StopPrice *decimal.Decimal
+	Quantity  *int
+}
+
+// linkedUser selects the ID of the trading account linked to the login
+// named by the given parameter.
+func linkedUser(param string) string {
+	return `SELECT u.id FROM users u INNER JOIN auth_user a ON a.id = u.auth_user_id WHERE a.username = ` + param
+}
+
+// ownedBy restricts a query of orders to those of the trading account
+// linked to the login named by the given parameter.
+func ownedBy(param string) string {
+	return `user_id = (` + linkedUser(param) + `)`
+}
+
+// LinkedUser returns the ID of the trading account linked to the login
+// named username. A trading account that merely shares the login's name is
+// not linked to it.
+func LinkedUser(q Querier, username string) (int, error) {
+	var userID int
+	err := q.QueryRow(linkedUser("$1"), username).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrUserNotFound
+	}
+	return userID, err
+}
+
+// FindOrder returns the order of username with the given ID. Orders of other
+// users are not found.
+func FindOrder(q Querier, username string, id int) (*models.Order, error) {
+	order, err := scanOrder(q.QueryRow(`SELECT `+orderColumns+` FROM orders WHERE id = $1 AND `+ownedBy("$2"), id, username))
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	return order, err
+}
+
+// ListOrders returns the orders of username matching filter, newest first.
+func ListOrders(q Querier, username string, filter OrderFilter) ([]models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE ` + ownedBy("$1") + `
+			AND ($2 = '' OR ticker = $2)
+			AND ($3 = '' OR status = $3)
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := q.Query(query, username, filter.Ticker, filter.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+
+	return orders, rows.Err()
+}
+
+// Cancel takes an open order of username off the book.
+func Cancel(tx *sql.Tx, username string, id int) (*models.Order, error) {
+	order, err := lockOrder(tx, username, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cancel(tx, order); err != nil {
+		return nil, err
+	}
+
+	if err := emitBook(tx, order.Ticker); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Amend replaces the prices or quantity of an open order of username while
+// the ticker is tradable. Reducing the quantity keeps the order's place in
+// the queue; any other change sends it to the back of the queue at its price
+// level. The amended order is matched again in case it now crosses the book.
+func Amend(tx *sql.Tx, username string, id int, amendment Amendment) (*models.Order, []Fill, error) {
+	// The stock is locked before the order, in the same sequence as Submit.
+	current, err := FindOrder(tx, username, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lastPrice, err := lockStock(tx, current.Ticker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := ensureTradable(tx, current.Ticker, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	order, err := lockOrder(tx, username, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	amended := *order
+	if amendment.Price != nil {
+		amended.Price = *amendment.Price
+	}
+	if amendment.StopPrice != nil {
+		amended.StopPrice = *amendment.StopPrice
+	}
+	if amendment.Quantity != nil {
+		amended.Quantity = *amendment.Quantity
+	}
+
+	// Market orders never rest, so only priced orders can be amended.
+	if amended.OrderType == Market || amended.Remaining() <= 0 {
+		return nil, nil, ErrInvalidOrder
+	}
+	if err := Validate(&amended); err != nil {
+		return nil, nil, err
+	}
+	if err := ensureInBand(tx, &amended); err != nil {
+		return nil, nil, err
+	}
+
+	if err := lockUser(tx, amended.UserID); err != nil {
+		return nil, nil, err
+	}
+	if err := ensureFunds(tx, &amended, lastPrice, order.Remaining()); err != nil {
+		return nil, nil, err
+	}
+
+	losePriority := !amended.Price.Equal(order.Price) || !amended.StopPrice.Equal(order.StopPrice) || amended.Quantity > order.Quantity
+	if !amended.Triggered {
+		amended.Triggered = stopReached(&amended, lastPrice)
+	}
+
+	query := `
+		UPDATE orders SET
+			price = $1, stop_price = $2, quantity = $3, triggered = $4, updated_at = now(),
+			priority = CASE WHEN $5 THEN nextval('orders_priority_seq') ELSE priority END
+		WHERE id = $6
+		RETURNING updated_at`
+	err = tx.QueryRow(query, nullPrice(amended.Price), nullPrice(amended.StopPrice), amended.Quantity,
+		amended.Triggered, losePriority, amended.ID).Scan(&amended.UpdatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fills, err := execute(tx, &amended)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(fills) > 0 {
+		if err := triggerStops(tx, amended.Ticker); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := emitBook(tx, amended.Ticker); err != nil {
+		return nil, nil, err
+	}
+
+	return &amended, fills, nil
+}
+
+// lockOrder loads an open order of username and locks its row.
+func lockOrder(tx *sql.Tx, username string, id int) (*models.Order, error) {
+	order, err := scanOrder(tx.QueryRow(`SELECT `+orderColumns+` FROM orders WHERE id = $1 AND `+ownedBy("$2")+` FOR UPDATE`, id, username))
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if order.Status != StatusNew && order.Status != StatusPartiallyFilled {
+		return nil, ErrOrderClosed
+	}
+
+	return order, nil
+}
@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	StockCreated  = "CREATE"
+	StockUpdated  = "UPDATE"
+	StockDelisted = "DELIST"
+)
+
+var (
+	ErrStockDelisted = errors.New("stock is delisted")
+	ErrInvalidStock  = errors.New("invalid stock settings")
+)
+
+// StockUpdate holds the stock settings an admin may change. Nil fields are
+// left as they are.
+type StockUpdate struct {
+	Price             *decimal.Decimal `json:"price" example:"150.25"`
+	InitialMargin     *decimal.Decimal `json:"initial_margin" example:"0.5"`
+	MaintenanceMargin *decimal.Decimal `json:"maintenance_margin" example:"0.25"`
+}
+
+// FieldChange is the value of a stock setting before and after a change.
+// Values are empty when the setting did not exist before or after it.
+type FieldChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// StockChange is one entry of the audit history of a stock: who created,
+// changed or delisted it, when, and which settings changed.
+type StockChange struct {
+	ID        int                    `json:"id"`
+	Ticker    string                 `json:"ticker"`
+	Action    string                 `json:"action"`
+	Changes   map[string]FieldChange `json:"changes"`
+	Reason    string                 `json:"reason,omitempty"`
+	ChangedBy string                 `json:"changed_by"`
+	ChangedAt time.Time              `json:"changed_at"`
+}
+
+type listedStock struct {
+	price             decimal.Decimal
+	initialMargin     decimal.Decimal
+	maintenanceMargin decimal.Decimal
+}
+
+// lockListedStock locks the stock row of ticker and returns its settings,
+// failing when the stock does not exist or has been delisted.
+func lockListedStock(tx *sql.Tx, ticker string) (*listedStock, error) {
+	var stock listedStock
+	var delistedAt *time.Time
+	query := `SELECT price, initial_margin, maintenance_margin, delisted_at FROM stocks WHERE ticker = $1 FOR UPDATE`
+	err := tx.QueryRow(query, ticker).Scan(&stock.price, &stock.initialMargin, &stock.maintenanceMargin, &delistedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrStockNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if delistedAt != nil {
+		return nil, ErrStockDelisted
+	}
+	return &stock, nil
+}
+
+// validate checks the settings update leaves current with: a positive price
+// in whole cents and margin rates with 0 < maintenance <= initial <= 1.
+func (update *StockUpdate) validate(current *listedStock) error {
+	if update.Price != nil && (!update.Price.IsPositive() || !update.Price.Equal(update.Price.Round(decimal.Cents, decimal.Down))) {
+		return ErrInvalidStock
+	}
+	initialMargin, maintenanceMargin := current.initialMargin, current.maintenanceMargin
+	if update.InitialMargin != nil {
+		initialMargin = *update.InitialMargin
+	}
+	if update.MaintenanceMargin != nil {
+		maintenanceMargin = *update.MaintenanceMargin
+	}
+	if !maintenanceMargin.IsPositive() || maintenanceMargin.GreaterThan(initialMargin) || initialMargin.GreaterThan(decimal.FromInt(1)) {
+		return ErrInvalidStock
+	}
+	return nil
+}
+
+// RecordStockChange appends a change made by changedBy to the audit
+// history of ticker.
+func RecordStockChange(tx *sql.Tx, ticker, action string, changes map[string]FieldChange, reason, changedBy string) (*StockChange, error) {
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	change := StockChange{Ticker: ticker, Action: action, Changes: changes, Reason: reason, ChangedBy: changedBy}
+	query := `
+		INSERT INTO stock_changes (ticker, action, changes, reason, changed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, changed_at`
+	err = tx.QueryRow(query, ticker, action, encoded, reason, changedBy).Scan(&change.ID, &change.ChangedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// UpdateStock applies update to ticker and records who made it. A new
+// price becomes the last price and is added to the price history; it does
+// not move the reference price of the price bands. The price is subject to
+// the price bands and circuit breaker of the ticker and triggers stop
+// orders like a trade. It returns nil when the update changes nothing.
+func UpdateStock(tx *sql.Tx, ticker string, update StockUpdate, reason, changedBy string) (*StockChange, error) {
+	current, err := lockListedStock(tx, ticker)
+	if err != nil {
+		return nil, err
+	}
+	if err := update.validate(current); err != nil {
+		return nil, err
+	}
+
+	changes := map[string]FieldChange{}
+	for _, field := range []struct {
+		name    string
+		current decimal.Decimal
+		value   *decimal.Decimal
+	}{
+		{"price", current.price, update.Price},
+		{"initial_margin", current.initialMargin, update.InitialMargin},
+		{"maintenance_margin", current.maintenanceMargin, update.MaintenanceMargin},
+	} {
+		if field.value != nil && !field.value.Equal(field.current) {
+			changes[field.name] = FieldChange{Old: field.current.String(), New: field.value.String()}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE stocks SET
+			initial_margin = COALESCE($1, initial_margin),
+			maintenance_margin = COALESCE($2, maintenance_margin)
+		WHERE ticker = $3`
+	if _, err := tx.Exec(query, update.InitialMargin, update.MaintenanceMargin, ticker); err != nil {
+		return nil, err
+	}
+	if _, ok := changes["price"]; ok {
+		if err := movePrice(tx, ticker, current.price, *update.Price, 0, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return RecordStockChange(tx, ticker, StockUpdated, changes, reason, changedBy)
+}
+
+// DelistStock stops trading in ticker for good. Open orders are cancelled
+// and scheduled corporate actions are called off; holders keep their
+// positions, and dividends already recorded are still paid.
+func DelistStock(tx *sql.Tx, ticker, reason, changedBy string) (*StockChange, error) {
+	if _, err := lockListedStock(tx, ticker); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE stocks SET delisted_at = now() WHERE ticker = $1`, ticker); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`SELECT `+orderColumns+` FROM orders WHERE ticker = $1 AND status IN ('NEW', 'PARTIALLY_FILLED') FOR UPDATE`, ticker)
+	if err != nil {
+		return nil, err
+	}
+	var orders []*models.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if err := cancel(tx, order); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE corporate_actions SET status = $1 WHERE ticker = $2 AND status = $3`, ActionCancelled, ticker, ActionScheduled)
+	if err != nil {
+		return nil, err
+	}
+	if err := emitBook(tx, ticker); err != nil {
+		return nil, err
+	}
+
+	changes := map[string]FieldChange{"listed": {Old: "true", New: "false"}}
+	return RecordStockChange(tx, ticker, StockDelisted, changes, reason, changedBy)
+}
+
+// StockHistory returns the audit history of ticker, oldest first.
+func StockHistory(q Querier, ticker string) ([]StockChange, error) {
+	query := `
+		SELECT id, ticker, action, changes, reason, changed_by, changed_at
+		FROM stock_changes
+		WHERE ticker = $1
+		ORDER BY changed_at, id`
+	rows, err := q.Query(query, ticker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []StockChange{}
+	for rows.Next() {
+		var change StockChange
+		var encoded []byte
+		err := rows.Scan(&change.ID, &change.Ticker, &change.Action, &encoded, &change.Reason, &change.ChangedBy, &change.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(encoded, &change.Changes); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,323 @@
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	CashAccount   = "CASH"
+	MarginAccount = "MARGIN"
+)
+
+var ErrMarginCall = errors.New("account is in a margin call")
+
+// Account values a user's cash and positions at last trade prices, in the
+// base currency. Cash is the base currency the user holds: SettledCash in
+// their cash account and UnsettledCash, received from trades that have not
+// settled yet, in their unsettled account. Cash, settled or not, and
+// positions in other currencies are valued at the configured exchange
+// rates. For margin accounts the requirements are the per-ticker initial
+// and maintenance margin rates applied to the gross value of every long and
+// short position. An account whose equity falls below its maintenance
+// requirement is in a margin call: until it is back above, it may only
+// place orders that reduce its existing positions.
+type Account struct {
+	UserID                 int             `json:"user_id"`
+	AccountType            string          `json:"account_type"`
+	Cash                   decimal.Decimal `json:"cash"`
+	SettledCash            decimal.Decimal `json:"settled_cash"`
+	UnsettledCash          decimal.Decimal `json:"unsettled_cash"`
+	ForeignCash            decimal.Decimal `json:"foreign_cash"`
+	MarketValue            decimal.Decimal `json:"market_value"`
+	Equity                 decimal.Decimal `json:"equity"`
+	InitialRequirement     decimal.Decimal `json:"initial_requirement"`
+	MaintenanceRequirement decimal.Decimal `json:"maintenance_requirement"`
+	ExcessEquity           decimal.Decimal `json:"excess_equity"`
+	MarginCall             bool            `json:"margin_call"`
+}
+
+// LoadAccount values the account of the given user.
+func LoadAccount(q Querier, userID int) (*Account, error) {
+	account := Account{UserID: userID}
+	err := q.QueryRow(`SELECT account_type, balance FROM users WHERE id = $1`, userID).Scan(&account.AccountType, &account.SettledCash)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	account.UnsettledCash, err = UnsettledCash(q, userID, fx.Base)
+	if err != nil {
+		return nil, err
+	}
+	account.Cash = account.SettledCash.Add(account.UnsettledCash)
+
+	unsettled, err := ledger.UnsettledBalances(q, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, cash := range unsettled {
+		if cash.Currency == fx.Base {
+			continue
+		}
+		rate, err := fx.Load(q, cash.Currency)
+		if err != nil {
+			return nil, err
+		}
+		account.ForeignCash = account.ForeignCash.Add(cash.Balance.Mul(rate))
+	}
+
+	query := `
+		SELECT c.currency, c.balance, r.rate
+		FROM cash_balances c
+		LEFT JOIN fx_rates r ON r.currency = c.currency
+		WHERE c.user_id = $1 AND c.balance <> 0`
+	rows, err := q.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var currency string
+		var balance decimal.Decimal
+		var rate *decimal.Decimal
+		if err := rows.Scan(&currency, &balance, &rate); err != nil {
+			return nil, err
+		}
+		if rate == nil {
+			return nil, fmt.Errorf("%w for %s", fx.ErrNoRate, currency)
+		}
+		account.ForeignCash = account.ForeignCash.Add(balance.Mul(*rate))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT p.quantity, s.price, s.initial_margin, s.maintenance_margin, s.currency, r.rate
+		FROM positions p
+		INNER JOIN stocks s ON s.ticker = p.ticker
+		LEFT JOIN fx_rates r ON r.currency = s.currency
+		WHERE p.user_id = $1 AND p.quantity <> 0`
+	rows, err = q.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var quantity int
+		var price, initialMargin, maintenanceMargin decimal.Decimal
+		var currency string
+		var rate *decimal.Decimal
+		if err := rows.Scan(&quantity, &price, &initialMargin, &maintenanceMargin, &currency, &rate); err != nil {
+			return nil, err
+		}
+		if currency != fx.Base {
+			if rate == nil {
+				return nil, fmt.Errorf("%w for %s", fx.ErrNoRate, currency)
+			}
+			price = price.Mul(*rate)
+		}
+		gross := price.MulInt(abs(quantity))
+		account.MarketValue = account.MarketValue.Add(price.MulInt(quantity))
+		account.InitialRequirement = account.InitialRequirement.Add(gross.Mul(initialMargin))
+		account.MaintenanceRequirement = account.MaintenanceRequirement.Add(gross.Mul(maintenanceMargin))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	account.Equity = account.Cash.Add(account.ForeignCash).Add(account.MarketValue)
+	account.ExcessEquity = account.Equity.Sub(account.InitialRequirement)
+	account.MarginCall = account.AccountType == MarginAccount && account.Equity.LessThan(account.MaintenanceRequirement)
+
+	return &account, nil
+}
+
+// BuyingPower returns the base-currency notional value of new positions the
+// account can open in a ticker with the given initial margin rate. Cash
+// accounts can only spend their settled base currency cash; margin accounts
+// borrow against unsettled cash like any other equity. Margin buying power
+// is rounded down to the cent.
+func (account *Account) BuyingPower(initialMargin decimal.Decimal) decimal.Decimal {
+	if account.AccountType != MarginAccount {
+		return decimal.Max(account.SettledCash, decimal.Zero)
+	}
+	if !account.ExcessEquity.IsPositive() {
+		return decimal.Zero
+	}
+	return account.ExcessEquity.Div(initialMargin, decimal.Down).Round(decimal.Cents, decimal.Down)
+}
+
+// InitialMargin returns the initial margin rate configured for ticker.
+func InitialMargin(q Querier, ticker string) (decimal.Decimal, error) {
+	var rate decimal.Decimal
+	err := q.QueryRow(`SELECT initial_margin FROM stocks WHERE ticker = $1`, ticker).Scan(&rate)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, ErrStockNotFound
+	}
+	return rate, err
+}
+
+// canAfford reports whether the account can pay amount in currency, worth
+// rate in the base currency, for a position in a ticker with the given
+// initial margin rate. Cash accounts first spend the settled cash they hold
+// in the currency and convert the rest from their settled base currency
+// cash; margin accounts need buying power for the whole amount.
+func (account *Account) canAfford(q Querier, currency string, rate, amount, initialMargin decimal.Decimal) (bool, error) {
+	if account.AccountType == MarginAccount {
+		return account.BuyingPower(initialMargin).Cmp(fx.ToBase(amount, rate, decimal.Ceiling)) >= 0, nil
+	}
+	need, err := baseCost(q, account.UserID, currency, rate, amount)
+	if err != nil {
+		return false, err
+	}
+	return account.BuyingPower(initialMargin).Cmp(need) >= 0, nil
+}
+
+// ensureFunds checks that the owner of an order about to rest on the book
+// can cover its open quantity. Buys need buying power for their limit value.
+// Sells need shares, except in margin accounts, which may sell short against
+// borrowed shares as long as buying power covers the short value. released
+// is the quantity of shares the order itself already commits, which is
+// counted as available when an existing order is amended. Accounts in a
+// margin call may only place orders that reduce their positions.
+func ensureFunds(tx *sql.Tx, order *models.Order, lastPrice decimal.Decimal, released int) error {
+	account, err := LoadAccount(tx, order.UserID)
+	if err != nil {
+		return err
+	}
+	if account.MarginCall {
+		reduces, err := reducesPosition(tx, order, released)
+		if err != nil {
+			return err
+		}
+		if !reduces {
+			return ErrMarginCall
+		}
+	}
+	initialMargin, err := InitialMargin(tx, order.Ticker)
+	if err != nil {
+		return err
+	}
+	currency, rate, err := TradingCurrency(tx, order.Ticker)
+	if err != nil {
+		return err
+	}
+
+	if order.Side == Buy {
+		// Market buys cannot be priced up front; they are checked fill by fill.
+		if order.Price.IsZero() {
+			return nil
+		}
+		ok, err := account.canAfford(tx, currency, rate, order.Price.MulInt(order.Remaining()), initialMargin)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInsufficientBalance
+		}
+		return nil
+	}
+
+	available, err := AvailableQuantity(tx, order.UserID, order.Ticker)
+	if err != nil {
+		return err
+	}
+	short := order.Remaining() - max(available+released, 0)
+	if short <= 0 {
+		return nil
+	}
+	if account.AccountType != MarginAccount {
+		return ErrInsufficientShares
+	}
+
+	price := order.Price
+	if price.IsZero() {
+		price = lastPrice
+	}
+	ok, err := account.canAfford(tx, currency, rate, price.MulInt(short), initialMargin)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+// reducesPosition reports whether order, together with the other open orders
+// on the same side of its ticker, only closes part of the user's position:
+// buys cover a short position and sells sell down a long one. released is the
+// open quantity the order itself already commits.
+func reducesPosition(tx *sql.Tx, order *models.Order, released int) (bool, error) {
+	held, err := HeldQuantity(tx, order.UserID, order.Ticker)
+	if err != nil {
+		return false, err
+	}
+
+	var committed int
+	query := `
+		SELECT COALESCE(SUM(quantity - filled_quantity), 0)
+		FROM orders
+		WHERE user_id = $1 AND ticker = $2 AND side = $3 AND status IN ('NEW', 'PARTIALLY_FILLED')`
+	if err := tx.QueryRow(query, order.UserID, order.Ticker, order.Side).Scan(&committed); err != nil {
+		return false, err
+	}
+	open := committed - released + order.Remaining()
+
+	if order.Side == Buy {
+		return held < 0 && open <= -held, nil
+	}
+	return held > 0 && open <= held, nil
+}
+
+// canSettle reports whether the owner of order still has the buying power or
+// shares needed for their side of fill, commission included.
+func canSettle(tx *sql.Tx, order *models.Order, fill Fill) (bool, error) {
+	if err := lockUser(tx, order.UserID); err != nil {
+		return false, err
+	}
+	account, err := LoadAccount(tx, order.UserID)
+	if err != nil {
+		return false, err
+	}
+	initialMargin, err := InitialMargin(tx, order.Ticker)
+	if err != nil {
+		return false, err
+	}
+
+	fee := fill.fee(order.Side)
+	if order.Side == Buy {
+		return account.canAfford(tx, fill.Currency, fill.FXRate, fill.Notional().Add(fee), initialMargin)
+	}
+
+	held, err := HeldQuantity(tx, order.UserID, order.Ticker)
+	if err != nil {
+		return false, err
+	}
+	short := fill.Volume - max(held, 0)
+	if short <= 0 {
+		if account.AccountType == MarginAccount {
+			return true, nil
+		}
+		return account.canAfford(tx, fill.Currency, fill.FXRate, fee.Sub(fill.Notional()), initialMargin)
+	}
+	if account.AccountType != MarginAccount {
+		return false, nil
+	}
+	return account.canAfford(tx, fill.Currency, fill.FXRate, fill.Price.MulInt(short).Add(fee), initialMargin)
+}
+
+func lockUser(tx *sql.Tx, userID int) error {
+	var id int
+	return tx.QueryRow(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id)
+}
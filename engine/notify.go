@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"database/sql"
+	"time"
+
+	"stock_exchange_Golang_project/feed"
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// OrderFill is the feed event of one side of a fill: Volume shares of the
+// user's order traded at Price, recorded as transaction TransactionID.
+type OrderFill struct {
+	OrderID       int             `json:"order_id"`
+	TransactionID int             `json:"transaction_id"`
+	Ticker        string          `json:"ticker"`
+	Side          string          `json:"side"`
+	Price         decimal.Decimal `json:"price"`
+	Volume        int             `json:"volume"`
+	Fee           decimal.Decimal `json:"fee"`
+	Liquidity     string          `json:"liquidity"`
+	Currency      string          `json:"currency"`
+	RealizedPnL   decimal.Decimal `json:"realized_pnl"`
+	Timestamp     time.Time       `json:"timestamp"`
+}
+
+// recordOrder adds the current state of order to its owner's feed.
+func recordOrder(tx *sql.Tx, order *models.Order) error {
+	return feed.Record(tx, order.UserID, feed.Order, order)
+}
+
+// recordFill adds the side of fill taken by the user to their feed.
+func recordFill(tx *sql.Tx, fill Fill, side string, transactionID int, realizedPnL decimal.Decimal) error {
+	event := OrderFill{
+		TransactionID: transactionID,
+		Ticker:        fill.Ticker,
+		Side:          side,
+		Price:         fill.Price,
+		Volume:        fill.Volume,
+		Liquidity:     fill.liquidity(side),
+		Currency:      fill.Currency,
+		RealizedPnL:   realizedPnL,
+		Timestamp:     fill.Timestamp,
+	}
+	userID := fill.BuyerID
+	event.OrderID, event.Fee = fill.BuyOrderID, fill.BuyerFee
+	if side == Sell {
+		userID = fill.SellerID
+		event.OrderID, event.Fee = fill.SellOrderID, fill.SellerFee
+	}
+	return feed.Record(tx, userID, feed.Fill, event)
+}
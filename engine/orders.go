@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"database/sql"
+
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// orderColumns lists the columns read by scanOrder, in order.
+const orderColumns = `id, user_id, ticker, side, order_type, time_in_force, COALESCE(price, 0), COALESCE(stop_price, 0),
+	triggered, quantity, filled_quantity, status, expires_at, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrder(row scanner) (*models.Order, error) {
+	var order models.Order
+	err := row.Scan(&order.ID, &order.UserID, &order.Ticker, &order.Side, &order.OrderType, &order.TimeInForce, &order.Price,
+		&order.StopPrice, &order.Triggered, &order.Quantity, &order.FilledQuantity, &order.Status, &order.ExpiresAt, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// bestOpposite returns the resting order with the highest priority that
+// crosses order, locking its row. Orders without a limit price cross every
+// resting order.
+func bestOpposite(tx *sql.Tx, order *models.Order) (*models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE ticker = $1 AND side = 'SELL' AND status IN ('NEW', 'PARTIALLY_FILLED') AND triggered
+			AND (expires_at IS NULL OR expires_at > now())
+			AND ($2::numeric IS NULL OR price <= $2) AND user_id <> $3
+		ORDER BY price ASC, priority ASC
+		LIMIT 1
+		FOR UPDATE`
+	if order.Side == Sell {
+		query = `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE ticker = $1 AND side = 'BUY' AND status IN ('NEW', 'PARTIALLY_FILLED') AND triggered
+			AND (expires_at IS NULL OR expires_at > now())
+			AND ($2::numeric IS NULL OR price >= $2) AND user_id <> $3
+		ORDER BY price DESC, priority ASC
+		LIMIT 1
+		FOR UPDATE`
+	}
+
+	return scanOrder(tx.QueryRow(query, order.Ticker, nullPrice(order.Price), order.UserID))
+}
+
+func cancel(tx *sql.Tx, order *models.Order) error {
+	order.Status = StatusCancelled
+	_, err := tx.Exec(`UPDATE orders SET status = $1, updated_at = now() WHERE id = $2`, order.Status, order.ID)
+	if err != nil {
+		return err
+	}
+	return recordOrder(tx, order)
+}
+
+func applyFill(order *models.Order, volume int) {
+	order.FilledQuantity += volume
+	if order.Remaining() == 0 {
+		order.Status = StatusFilled
+	} else {
+		order.Status = StatusPartiallyFilled
+	}
+}
+
+// nullPrice stores an unset (zero) price as NULL.
+func nullPrice(price decimal.Decimal) *decimal.Decimal {
+	if !price.IsPositive() {
+		return nil
+	}
+	return &price
+}
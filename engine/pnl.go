@@ -0,0 +1,334 @@
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// Cost-basis methods decide which shares a closing trade disposes of, and
+// so the P&L it realizes. FIFO closes the oldest lots first and LIFO the
+// newest; AVERAGE closes at the position's average cost.
+const (
+	FIFO        = "FIFO"
+	LIFO        = "LIFO"
+	AverageCost = "AVERAGE"
+)
+
+var ErrInvalidCostBasis = errors.New("cost basis method must be FIFO, LIFO or AVERAGE")
+
+// PortfolioPosition is an open position valued at the last trade price, in
+// the ticker's currency.
+type PortfolioPosition struct {
+	Ticker        string          `json:"ticker"`
+	Currency      string          `json:"currency"`
+	Quantity      int             `json:"quantity"`
+	LastPrice     decimal.Decimal `json:"last_price"`
+	AverageCost   decimal.Decimal `json:"average_cost"`
+	CostBasis     decimal.Decimal `json:"cost_basis"`
+	MarketValue   decimal.Decimal `json:"market_value"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl"`
+	RealizedPnL   decimal.Decimal `json:"realized_pnl"`
+}
+
+// Portfolio summarizes what a user holds and what they made. Totals are in
+// the base currency: open positions at current exchange rates, realized
+// P&L and fees at the rates of the trades that produced them. Realized P&L
+// is before commissions.
+type Portfolio struct {
+	UserID          int                 `json:"user_id"`
+	CostBasisMethod string              `json:"cost_basis_method"`
+	Cash            decimal.Decimal     `json:"cash"`
+	MarketValue     decimal.Decimal     `json:"market_value"`
+	CostBasis       decimal.Decimal     `json:"cost_basis"`
+	UnrealizedPnL   decimal.Decimal     `json:"unrealized_pnl"`
+	RealizedPnL     decimal.Decimal     `json:"realized_pnl"`
+	Fees            decimal.Decimal     `json:"fees"`
+	Equity          decimal.Decimal     `json:"equity"`
+	Positions       []PortfolioPosition `json:"positions"`
+}
+
+// CostBasisMethod returns the cost-basis method of the user's account.
+func CostBasisMethod(q Querier, userID int) (string, error) {
+	var method string
+	err := q.QueryRow(`SELECT cost_basis_method FROM users WHERE id = $1`, userID).Scan(&method)
+	if err == sql.ErrNoRows {
+		return "", ErrUserNotFound
+	}
+	return method, err
+}
+
+// ValidCostBasisMethod reports whether method is FIFO, LIFO or AVERAGE.
+func ValidCostBasisMethod(method string) bool {
+	return method == FIFO || method == LIFO || method == AverageCost
+}
+
+// SetCostBasisMethod changes the cost-basis method of the user's account.
+// It applies to trades that close positions from then on; P&L already
+// realized is not restated.
+func SetCostBasisMethod(q Querier, userID int, method string) error {
+	if !ValidCostBasisMethod(method) {
+		return ErrInvalidCostBasis
+	}
+	var id int
+	err := q.QueryRow(`UPDATE users SET cost_basis_method = $1 WHERE id = $2 RETURNING id`, method, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+type taxLot struct {
+	id       int
+	quantity int
+	cost     decimal.Decimal
+}
+
+// lockLots returns the open tax lots of the user in ticker, oldest first
+// unless newestFirst is set, and locks them until the end of tx.
+func lockLots(tx *sql.Tx, userID int, ticker string, newestFirst bool) ([]taxLot, error) {
+	query := `
+		SELECT id, quantity, cost FROM tax_lots
+		WHERE user_id = $1 AND ticker = $2 AND quantity <> 0
+		ORDER BY opened_at, id
+		FOR UPDATE`
+	if newestFirst {
+		query = `
+		SELECT id, quantity, cost FROM tax_lots
+		WHERE user_id = $1 AND ticker = $2 AND quantity <> 0
+		ORDER BY opened_at DESC, id DESC
+		FOR UPDATE`
+	}
+	rows, err := tx.Query(query, userID, ticker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lots []taxLot
+	for rows.Next() {
+		var lot taxLot
+		if err := rows.Scan(&lot.id, &lot.quantity, &lot.cost); err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+	return lots, rows.Err()
+}
+
+func (lot taxLot) save(tx *sql.Tx) error {
+	if lot.quantity == 0 {
+		_, err := tx.Exec(`DELETE FROM tax_lots WHERE id = $1`, lot.id)
+		return err
+	}
+	_, err := tx.Exec(`UPDATE tax_lots SET quantity = $1, cost = $2 WHERE id = $3`, lot.quantity, lot.cost, lot.id)
+	return err
+}
+
+// updateLots applies a trade of delta shares at price to the user's tax
+// lots and returns the P&L it realized, rounded half to even to the cent.
+// quantity and averageCost describe the position before the trade. The
+// part of the trade that reduces the position closes lots in the order of
+// the account's cost-basis method; the rest opens a new lot at price.
+func updateLots(tx *sql.Tx, userID int, ticker string, quantity, delta int, price, averageCost decimal.Decimal) (decimal.Decimal, error) {
+	realized := decimal.Zero
+	closing := 0
+	if quantity != 0 && (quantity > 0) != (delta > 0) {
+		closing = min(abs(delta), abs(quantity))
+	}
+
+	if closing > 0 {
+		method, err := CostBasisMethod(tx, userID)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		lots, err := lockLots(tx, userID, ticker, method == LIFO)
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		// sign turns a closed share count into the change of a lot:
+		// closing a long position sells shares, covering a short buys them.
+		sign := 1
+		if quantity < 0 {
+			sign = -1
+		}
+		remaining := closing
+		for _, lot := range lots {
+			if remaining == 0 {
+				break
+			}
+			taken := min(abs(lot.quantity), remaining)
+			cost := lot.cost
+			if method == AverageCost {
+				cost = averageCost
+			}
+			realized = realized.Add(price.Sub(cost).MulInt(taken * sign))
+			lot.quantity -= taken * sign
+			if err := lot.save(tx); err != nil {
+				return decimal.Zero, err
+			}
+			remaining -= taken
+		}
+		// Shares without a lot close at the position's average cost.
+		realized = realized.Add(price.Sub(averageCost).MulInt(remaining * sign))
+	}
+
+	if opening := abs(delta) - closing; opening > 0 {
+		if delta < 0 {
+			opening = -opening
+		}
+		_, err := tx.Exec(`INSERT INTO tax_lots (user_id, ticker, quantity, cost) VALUES ($1, $2, $3, $4)`, userID, ticker, opening, price)
+		if err != nil {
+			return decimal.Zero, err
+		}
+	}
+
+	return realized.Round(decimal.Cents, decimal.HalfEven), nil
+}
+
+// splitLots restates the user's lots in ticker in post-split shares so that
+// they add up to quantity, the position after the split. Whole shares lost
+// by rounding each lot down are given back to the oldest lots.
+func splitLots(tx *sql.Tx, action *CorporateAction, userID, quantity int) error {
+	lots, err := lockLots(tx, userID, action.Ticker, false)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for i := range lots {
+		lots[i].quantity, _ = action.splitQuantity(lots[i].quantity)
+		lots[i].cost = action.splitCost(lots[i].cost)
+		total += lots[i].quantity
+	}
+	for i := 0; i < len(lots) && total != quantity; i++ {
+		step := 1
+		if quantity < total {
+			step = -1
+		}
+		lots[i].quantity += step
+		total += step
+	}
+
+	for _, lot := range lots {
+		if err := lot.save(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadPortfolio values the user's open positions at last trade prices and
+// sums the P&L they have realized.
+func LoadPortfolio(q Querier, userID int) (*Portfolio, error) {
+	account, err := LoadAccount(q, userID)
+	if err != nil {
+		return nil, err
+	}
+	method, err := CostBasisMethod(q, userID)
+	if err != nil {
+		return nil, err
+	}
+	portfolio := Portfolio{
+		UserID:          userID,
+		CostBasisMethod: method,
+		Cash:            account.Cash.Add(account.ForeignCash).Round(decimal.Cents, decimal.HalfEven),
+		Positions:       []PortfolioPosition{},
+	}
+
+	lotCosts := map[string]decimal.Decimal{}
+	rows, err := q.Query(`SELECT ticker, SUM(quantity * cost) FROM tax_lots WHERE user_id = $1 GROUP BY ticker`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ticker string
+		var cost decimal.Decimal
+		if err := rows.Scan(&ticker, &cost); err != nil {
+			return nil, err
+		}
+		lotCosts[ticker] = cost
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	realized := map[string]decimal.Decimal{}
+	query := `
+		SELECT ticker, SUM(realized_pnl), SUM(realized_pnl * fx_rate), SUM(fee * fx_rate)
+		FROM transactions
+		WHERE user_id = $1
+		GROUP BY ticker`
+	rows, err = q.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ticker string
+		var pnl, basePnL, baseFees decimal.Decimal
+		if err := rows.Scan(&ticker, &pnl, &basePnL, &baseFees); err != nil {
+			return nil, err
+		}
+		realized[ticker] = pnl
+		portfolio.RealizedPnL = portfolio.RealizedPnL.Add(basePnL)
+		portfolio.Fees = portfolio.Fees.Add(baseFees)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	portfolio.RealizedPnL = portfolio.RealizedPnL.Round(decimal.Cents, decimal.HalfEven)
+	portfolio.Fees = portfolio.Fees.Round(decimal.Cents, decimal.HalfEven)
+
+	query = `
+		SELECT p.ticker, s.currency, r.rate, p.quantity, s.price, p.average_cost
+		FROM positions p
+		INNER JOIN stocks s ON s.ticker = p.ticker
+		LEFT JOIN fx_rates r ON r.currency = s.currency
+		WHERE p.user_id = $1 AND p.quantity <> 0
+		ORDER BY p.ticker`
+	rows, err = q.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var position PortfolioPosition
+		var rate *decimal.Decimal
+		err := rows.Scan(&position.Ticker, &position.Currency, &rate, &position.Quantity, &position.LastPrice, &position.AverageCost)
+		if err != nil {
+			return nil, err
+		}
+		if position.Currency == fx.Base {
+			one := decimal.FromInt(1)
+			rate = &one
+		} else if rate == nil {
+			return nil, fmt.Errorf("%w for %s", fx.ErrNoRate, position.Currency)
+		}
+
+		position.CostBasis = position.AverageCost.MulInt(position.Quantity)
+		if cost, ok := lotCosts[position.Ticker]; ok && method != AverageCost {
+			position.CostBasis = cost
+		}
+		position.CostBasis = position.CostBasis.Round(decimal.Cents, decimal.HalfEven)
+		position.MarketValue = position.LastPrice.MulInt(position.Quantity)
+		position.UnrealizedPnL = position.MarketValue.Sub(position.CostBasis)
+		position.RealizedPnL = realized[position.Ticker]
+		portfolio.Positions = append(portfolio.Positions, position)
+
+		portfolio.MarketValue = portfolio.MarketValue.Add(fx.ToBase(position.MarketValue, *rate, decimal.HalfEven))
+		portfolio.CostBasis = portfolio.CostBasis.Add(fx.ToBase(position.CostBasis, *rate, decimal.HalfEven))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	portfolio.UnrealizedPnL = portfolio.MarketValue.Sub(portfolio.CostBasis)
+	portfolio.Equity = portfolio.Cash.Add(portfolio.MarketValue)
+	return &portfolio, nil
+}
@@ -0,0 +1,187 @@
+package engine
+
+import (
+	"database/sql"
+
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// costPlaces is the number of fractional digits of positions.average_cost.
+const costPlaces = 4
+
+// HeldQuantity returns the number of shares of ticker held by the user and
+// locks the position row until the end of tx. Short positions are negative.
+func HeldQuantity(tx *sql.Tx, userID int, ticker string) (int, error) {
+	var quantity int
+	err := tx.QueryRow(`SELECT quantity FROM positions WHERE user_id = $1 AND ticker = $2 FOR UPDATE`, userID, ticker).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return quantity, err
+}
+
+// SettledQuantity returns the shares of ticker held by the user less those
+// bought or sold in trades that have not settled yet.
+func SettledQuantity(q Querier, userID int, ticker string) (int, error) {
+	var settled int
+	query := `SELECT quantity - unsettled_quantity FROM positions WHERE user_id = $1 AND ticker = $2`
+	err := q.QueryRow(query, userID, ticker).Scan(&settled)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return settled, err
+}
+
+// Holding is the number of shares of a ticker a user holds. Short holdings
+// are negative.
+type Holding struct {
+	UserID   int
+	Quantity int
+}
+
+// SettledHoldings returns every non-zero holding of ticker as settled by
+// the end of date, formatted YYYY-MM-DD: each position less the shares of
+// its trades that settle after date, restated by the splits since the
+// trade.
+func SettledHoldings(q Querier, ticker, date string) ([]Holding, error) {
+	query := `
+		SELECT user_id, quantity FROM (
+			SELECT p.user_id, p.quantity - COALESCE((
+				SELECT SUM(TRUNC(s.quantity * t.split_factor))
+				FROM settlements s
+				INNER JOIN transactions t ON t.id = s.transaction_id
+				WHERE s.user_id = p.user_id AND s.ticker = p.ticker AND s.settlement_date > $2
+			), 0)::int AS quantity
+			FROM positions p
+			WHERE p.ticker = $1
+		) settled
+		WHERE quantity <> 0
+		ORDER BY user_id`
+	rows, err := q.Query(query, ticker, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holdings []Holding
+	for rows.Next() {
+		var holding Holding
+		if err := rows.Scan(&holding.UserID, &holding.Quantity); err != nil {
+			return nil, err
+		}
+		holdings = append(holdings, holding)
+	}
+	return holdings, rows.Err()
+}
+
+// Positions returns the open positions of the user ordered by ticker, each
+// with its settled quantity.
+func Positions(q Querier, userID int) ([]models.Position, error) {
+	query := `
+		SELECT user_id, ticker, quantity, quantity - unsettled_quantity, average_cost
+		FROM positions
+		WHERE user_id = $1 AND quantity <> 0
+		ORDER BY ticker`
+	rows, err := q.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := []models.Position{}
+	for rows.Next() {
+		var position models.Position
+		err := rows.Scan(&position.UserID, &position.Ticker, &position.Quantity, &position.SettledQuantity, &position.AverageCost)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	return positions, rows.Err()
+}
+
+// AvailableQuantity returns the shares of ticker the user may still offer for
+// sale: the held quantity less whatever is already committed to open SELL
+// orders.
+func AvailableQuantity(tx *sql.Tx, userID int, ticker string) (int, error) {
+	held, err := HeldQuantity(tx, userID, ticker)
+	if err != nil {
+		return 0, err
+	}
+
+	var committed int
+	query := `
+		SELECT COALESCE(SUM(quantity - filled_quantity), 0)
+		FROM orders
+		WHERE user_id = $1 AND ticker = $2 AND side = 'SELL' AND status IN ('NEW', 'PARTIALLY_FILLED')`
+	if err := tx.QueryRow(query, userID, ticker).Scan(&committed); err != nil {
+		return 0, err
+	}
+
+	return held - committed, nil
+}
+
+// UpdatePosition applies a trade of delta shares at price to the user's
+// position: positive for a purchase, negative for a sale. Trades that grow a
+// long or short position blend price into its average cost; trades that
+// shrink it leave the cost unchanged, and a trade that flips the position
+// from long to short or back starts the new side at price. Blended costs
+// are rounded half to even to the four places the positions table keeps.
+// The trade is also applied to the user's tax lots, and the P&L realized by
+// the part of it that closes the position is returned.
+func UpdatePosition(tx *sql.Tx, userID int, ticker string, delta int, price decimal.Decimal) (decimal.Decimal, error) {
+	var quantity int
+	var averageCost decimal.Decimal
+	err := tx.QueryRow(`SELECT quantity, average_cost FROM positions WHERE user_id = $1 AND ticker = $2 FOR UPDATE`,
+		userID, ticker).Scan(&quantity, &averageCost)
+	if err != nil && err != sql.ErrNoRows {
+		return decimal.Zero, err
+	}
+
+	realized, err := updateLots(tx, userID, ticker, quantity, delta, price, averageCost)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	updated := quantity + delta
+	switch {
+	case updated == 0:
+		averageCost = decimal.Zero
+	case quantity == 0 || (quantity > 0) == (delta > 0):
+		cost := averageCost.MulInt(abs(quantity)).Add(price.MulInt(abs(delta)))
+		averageCost = cost.DivInt(abs(updated), decimal.HalfEven).Round(costPlaces, decimal.HalfEven)
+	case (quantity > 0) != (updated > 0):
+		averageCost = price
+	}
+
+	query := `
+		INSERT INTO positions (user_id, ticker, quantity, average_cost)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, ticker) DO UPDATE SET
+			quantity = EXCLUDED.quantity,
+			average_cost = EXCLUDED.average_cost`
+	if _, err := tx.Exec(query, userID, ticker, updated, averageCost); err != nil {
+		return decimal.Zero, err
+	}
+	return realized, nil
+}
+
+// IssueShares credits quantity newly issued shares of ticker to the user at
+// price and records the issuance so that the position can be reconciled
+// against the trade history.
+func IssueShares(tx *sql.Tx, userID int, ticker string, quantity int, price decimal.Decimal) error {
+	if _, err := UpdatePosition(tx, userID, ticker, quantity, price); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO share_adjustments (user_id, ticker, quantity, reason) VALUES ($1, $2, $3, 'ISSUE')`,
+		userID, ticker, quantity)
+	return err
+}
+
+func abs(quantity int) int {
+	if quantity < 0 {
+		return -quantity
+	}
+	return quantity
+}
@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/lib/pq"
+)
+
+// maxSettlementDays bounds the settlement cycle an admin can configure.
+const maxSettlementDays = 5
+
+const (
+	PhaseClosed  = "CLOSED"
+	PhasePreOpen = "PRE_OPEN"
+	PhaseOpen    = "OPEN"
+)
+
+var (
+	ErrMarketClosed  = errors.New("market is closed")
+	ErrTradingHalted = errors.New("trading is halted")
+)
+
+// Holiday is a date on which the exchange does not trade.
+type Holiday struct {
+	Date        string `json:"date" example:"2024-12-25"`
+	Description string `json:"description" example:"Christmas Day"`
+}
+
+// Calendar describes the trading week of the exchange. Times of day are in
+// the calendar's timezone and formatted HH:MM; trading days are weekday
+// numbers with Sunday as 0. Trades settle SettlementDays trading days after
+// the trade date.
+type Calendar struct {
+	Timezone       string    `json:"timezone" example:"America/New_York"`
+	PreOpen        string    `json:"pre_open" example:"04:00"`
+	Open           string    `json:"open" example:"09:30"`
+	Close          string    `json:"close" example:"16:00"`
+	TradingDays    []int64   `json:"trading_days"`
+	SettlementDays int       `json:"settlement_days" example:"1"`
+	Holidays       []Holiday `json:"holidays"`
+
+	location *time.Location
+	preOpen  time.Duration
+	open     time.Duration
+	close    time.Duration
+}
+
+// LoadCalendar reads the exchange calendar and its holidays.
+func LoadCalendar(q Querier) (*Calendar, error) {
+	var calendar Calendar
+	query := `
+		SELECT timezone, to_char(pre_open_time, 'HH24:MI'), to_char(open_time, 'HH24:MI'), to_char(close_time, 'HH24:MI'), trading_days, settlement_days
+		FROM market_calendar
+		WHERE id = 1`
+	err := q.QueryRow(query).Scan(&calendar.Timezone, &calendar.PreOpen, &calendar.Open, &calendar.Close,
+		pq.Array(&calendar.TradingDays), &calendar.SettlementDays)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`SELECT to_char(date, 'YYYY-MM-DD'), description FROM market_holidays ORDER BY date`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	calendar.Holidays = []Holiday{}
+	for rows.Next() {
+		var holiday Holiday
+		if err := rows.Scan(&holiday.Date, &holiday.Description); err != nil {
+			return nil, err
+		}
+		calendar.Holidays = append(calendar.Holidays, holiday)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := calendar.parse(); err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// Validate checks that the calendar's timezone and times can be parsed,
+// that the sessions are ordered pre-open, open, close, and that the
+// settlement cycle is between T+0 and T+5.
+func (calendar *Calendar) Validate() error {
+	return calendar.parse()
+}
+
+func (calendar *Calendar) parse() error {
+	location, err := time.LoadLocation(calendar.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q", calendar.Timezone)
+	}
+	calendar.location = location
+
+	for _, field := range []struct {
+		value string
+		into  *time.Duration
+	}{
+		{calendar.PreOpen, &calendar.preOpen},
+		{calendar.Open, &calendar.open},
+		{calendar.Close, &calendar.close},
+	} {
+		clock, err := time.Parse("15:04", field.value)
+		if err != nil {
+			return fmt.Errorf("invalid time of day %q", field.value)
+		}
+		*field.into = time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute
+	}
+
+	if calendar.preOpen > calendar.open || calendar.open >= calendar.close {
+		return errors.New("sessions must be ordered pre_open <= open < close")
+	}
+	for _, day := range calendar.TradingDays {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid trading day %d", day)
+		}
+	}
+	if calendar.SettlementDays < 0 || calendar.SettlementDays > maxSettlementDays {
+		return fmt.Errorf("settlement_days must be between 0 and %d", maxSettlementDays)
+	}
+	return nil
+}
+
+// IsTradingDay reports whether the exchange trades on the calendar date of t.
+func (calendar *Calendar) IsTradingDay(t time.Time) bool {
+	local := t.In(calendar.location)
+	date := calendar.Date(t)
+	for _, holiday := range calendar.Holidays {
+		if holiday.Date == date {
+			return false
+		}
+	}
+	for _, day := range calendar.TradingDays {
+		if time.Weekday(day) == local.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// Phase returns the trading phase of the exchange at t.
+func (calendar *Calendar) Phase(t time.Time) string {
+	if !calendar.IsTradingDay(t) {
+		return PhaseClosed
+	}
+
+	sinceMidnight := t.Sub(calendar.midnight(t))
+	switch {
+	case sinceMidnight >= calendar.open && sinceMidnight < calendar.close:
+		return PhaseOpen
+	case sinceMidnight >= calendar.preOpen && sinceMidnight < calendar.open:
+		return PhasePreOpen
+	default:
+		return PhaseClosed
+	}
+}
+
+// NextOpen returns the start of the first regular session after t.
+func (calendar *Calendar) NextOpen(t time.Time) time.Time {
+	return calendar.next(t, calendar.open)
+}
+
+// NextClose returns the end of the current regular session, or of the next
+// one when the market is not open at t.
+func (calendar *Calendar) NextClose(t time.Time) time.Time {
+	return calendar.next(t, calendar.close)
+}
+
+// next returns the first instant after t that falls offset past midnight on
+// a trading day. The search is bounded to a year so that a calendar with no
+// trading days cannot loop forever.
+func (calendar *Calendar) next(t time.Time, offset time.Duration) time.Time {
+	day := calendar.midnight(t)
+	for i := 0; i < 366; i++ {
+		candidate := day.Add(offset)
+		if candidate.After(t) && calendar.IsTradingDay(candidate) {
+			return candidate
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// Date returns the calendar date of t in the exchange timezone, formatted
+// YYYY-MM-DD.
+func (calendar *Calendar) Date(t time.Time) string {
+	return t.In(calendar.location).Format("2006-01-02")
+}
+
+// SettlementDate returns the date, formatted YYYY-MM-DD, on which a trade
+// executed at t settles: SettlementDays trading days after its trade date.
+// The search is bounded to a year like next.
+func (calendar *Calendar) SettlementDate(t time.Time) string {
+	day := calendar.midnight(t)
+	for remaining, i := calendar.SettlementDays, 0; remaining > 0 && i < 366; i++ {
+		day = day.AddDate(0, 0, 1)
+		if calendar.IsTradingDay(day) {
+			remaining--
+		}
+	}
+	return calendar.Date(day)
+}
+
+func (calendar *Calendar) midnight(t time.Time) time.Time {
+	year, month, day := t.In(calendar.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, calendar.location)
+}
+
+// ensureTradable rejects order entry on ticker unless it is listed, the
+// market is in its regular session and trading in the ticker is not halted.
+func ensureTradable(tx *sql.Tx, ticker string, now time.Time) (*Calendar, error) {
+	var delisted bool
+	if err := tx.QueryRow(`SELECT delisted_at IS NOT NULL FROM stocks WHERE ticker = $1`, ticker).Scan(&delisted); err != nil {
+		return nil, err
+	}
+	if delisted {
+		return nil, ErrStockDelisted
+	}
+
+	calendar, err := LoadCalendar(tx)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.Phase(now) != PhaseOpen {
+		return nil, ErrMarketClosed
+	}
+
+	halted, err := IsHalted(tx, ticker)
+	if err != nil {
+		return nil, err
+	}
+	if halted {
+		return nil, ErrTradingHalted
+	}
+
+	return calendar, nil
+}
@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"stock_exchange_Golang_project/events"
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	SettlementPending = "PENDING"
+	SettlementSettled = "SETTLED"
+)
+
+// Settlement is what one side of a trade owes or is owed until the trade
+// settles: Quantity shares, positive when received, and Amount cash in
+// Currency, commission included, positive when received. Cash paid leaves
+// the user's cash account when the trade executes; cash received waits in
+// their unsettled account, where cash accounts cannot spend it and nobody
+// can withdraw it, and moves to their cash account on the settlement date.
+// Shares are part of the position from the start but count as unsettled
+// until then.
+type Settlement struct {
+	ID             int             `json:"id"`
+	TransactionID  int             `json:"transaction_id"`
+	UserID         int             `json:"user_id"`
+	Ticker         string          `json:"ticker"`
+	Quantity       int             `json:"quantity"`
+	Currency       string          `json:"currency"`
+	Amount         decimal.Decimal `json:"amount"`
+	TradeDate      string          `json:"trade_date"`
+	SettlementDate string          `json:"settlement_date"`
+	Status         string          `json:"status"`
+	SettledAt      *time.Time      `json:"settled_at,omitempty"`
+}
+
+const settlementColumns = `id, transaction_id, user_id, ticker, quantity, currency, amount,
+	to_char(trade_date, 'YYYY-MM-DD'), to_char(settlement_date, 'YYYY-MM-DD'), status, settled_at`
+
+func scanSettlement(row scanner) (*Settlement, error) {
+	var settlement Settlement
+	err := row.Scan(&settlement.ID, &settlement.TransactionID, &settlement.UserID, &settlement.Ticker, &settlement.Quantity,
+		&settlement.Currency, &settlement.Amount, &settlement.TradeDate, &settlement.SettlementDate, &settlement.Status,
+		&settlement.SettledAt)
+	if err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+// settlesLater reports whether fill settles after its trade date. Trades on
+// a T+0 cycle are settled on the spot.
+func (fill Fill) settlesLater() bool {
+	return fill.SettlementDate > fill.TradeDate
+}
+
+// proceedsAccount returns the account the cash a party receives from fill
+// is booked to: their unsettled account until the trade settles, or
+// cashAccount if it settles on the spot or the party receives nothing.
+func proceedsAccount(tx *sql.Tx, fill Fill, userID int, cashAccount string, amount decimal.Decimal) (string, error) {
+	if !fill.settlesLater() || !amount.IsPositive() {
+		return cashAccount, nil
+	}
+	return ledger.CurrencyAccount(tx, ledger.UnsettledAccount(userID), fill.Currency)
+}
+
+// recordSettlement books the obligation created by one side of fill,
+// recorded as transaction transactionID, and counts the shares it moves as
+// unsettled until the settlement date.
+func recordSettlement(tx *sql.Tx, fill Fill, transactionID, userID, quantity int, amount decimal.Decimal) error {
+	status := SettlementPending
+	var settledAt *time.Time
+	if !fill.settlesLater() {
+		status = SettlementSettled
+		settledAt = &fill.Timestamp
+	}
+	query := `
+		INSERT INTO settlements (transaction_id, user_id, ticker, quantity, currency, amount, trade_date, settlement_date, status, settled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := tx.Exec(query, transactionID, userID, fill.Ticker, quantity, fill.Currency, amount, fill.TradeDate,
+		fill.SettlementDate, status, settledAt)
+	if err != nil || status == SettlementSettled {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE positions SET unsettled_quantity = unsettled_quantity + $1 WHERE user_id = $2 AND ticker = $3`,
+		quantity, userID, fill.Ticker)
+	return err
+}
+
+// UnsettledCash returns the cash in currency the user has received from
+// trades that have not settled yet: the balance of their unsettled account.
+func UnsettledCash(q Querier, userID int, currency string) (decimal.Decimal, error) {
+	return ledger.UnsettledBalance(q, userID, currency)
+}
+
+// SettledCash returns the cash the user holds in currency. Cash received
+// from trades only reaches the user's cash account when they settle, so it
+// is all settled.
+func SettledCash(q Querier, userID int, currency string) (decimal.Decimal, error) {
+	return ledger.CashBalance(q, userID, currency)
+}
+
+// Settlements lists the settlement obligations of a user, latest trade
+// first. An empty status lists every obligation.
+func Settlements(q Querier, userID int, status string) ([]Settlement, error) {
+	query := `SELECT ` + settlementColumns + ` FROM settlements
+		WHERE user_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY trade_date DESC, id DESC`
+	rows, err := q.Query(query, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settlements := []Settlement{}
+	for rows.Next() {
+		settlement, err := scanSettlement(rows)
+		if err != nil {
+			return nil, err
+		}
+		settlements = append(settlements, *settlement)
+	}
+	return settlements, rows.Err()
+}
+
+// SettleTrades settles every pending obligation whose settlement date has
+// been reached and returns how many it settled. The cash each obligation
+// receives moves from the user's unsettled account to their cash account,
+// and the shares it moves stop counting as unsettled.
+func SettleTrades(db *sql.DB, now time.Time) (int, error) {
+	calendar, err := LoadCalendar(db)
+	if err != nil {
+		return 0, err
+	}
+	today := calendar.Date(now)
+
+	settled := 0
+	err = RunInTx(db, func(tx *sql.Tx) error {
+		query := `
+			UPDATE settlements SET status = $1, settled_at = $2
+			WHERE status = $3 AND settlement_date <= $4
+			RETURNING ` + settlementColumns
+		rows, err := tx.Query(query, SettlementSettled, now, SettlementPending, today)
+		if err != nil {
+			return err
+		}
+		var settlements []*Settlement
+		for rows.Next() {
+			settlement, err := scanSettlement(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			settlements = append(settlements, settlement)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, settlement := range settlements {
+			if err := settleObligation(tx, settlement); err != nil {
+				return err
+			}
+			emit(tx, events.Event{Type: events.Settlement, Ticker: settlement.Ticker, UserID: settlement.UserID, Data: settlement})
+		}
+		settled = len(settlements)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("settle trades: %w", err)
+	}
+	return settled, nil
+}
+
+// settleObligation moves the cash and shares of a settled obligation out of
+// the user's unsettled balances. Its shares are restated by the splits
+// since the trade.
+func settleObligation(tx *sql.Tx, settlement *Settlement) error {
+	query := `
+		UPDATE positions SET unsettled_quantity = unsettled_quantity - (
+			SELECT TRUNC($1 * split_factor) FROM transactions WHERE id = $2
+		)
+		WHERE user_id = $3 AND ticker = $4`
+	_, err := tx.Exec(query, settlement.Quantity, settlement.TransactionID, settlement.UserID, settlement.Ticker)
+	if err != nil || !settlement.Amount.IsPositive() {
+		return err
+	}
+
+	unsettled, err := ledger.CurrencyAccount(tx, ledger.UnsettledAccount(settlement.UserID), settlement.Currency)
+	if err != nil {
+		return err
+	}
+	cash, err := ledger.CurrencyAccount(tx, ledger.UserAccount(settlement.UserID), settlement.Currency)
+	if err != nil {
+		return err
+	}
+	return ledger.Transfer(tx, ledger.EntrySettlement, fmt.Sprintf("Settlement of %d %s", abs(settlement.Quantity), settlement.Ticker),
+		fmt.Sprintf("transactions:%d", settlement.TransactionID), unsettled, cash, settlement.Amount)
+}
+
+// RunSettlement calls SettleTrades every interval until the process exits.
+func RunSettlement(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		settled, err := SettleTrades(db, now)
+		if err != nil {
+			log.Printf("Failed to settle trades: %v", err)
+			continue
+		}
+		if settled > 0 {
+			log.Printf("Settled %d trade obligations", settled)
+		}
+	}
+}
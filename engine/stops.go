@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"database/sql"
+
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+func isStop(order *models.Order) bool {
+	return order.OrderType == Stop || order.OrderType == StopLimit
+}
+
+// stopReached reports whether lastPrice has reached the stop price of order:
+// at or above it for a buy stop, at or below it for a sell stop.
+func stopReached(order *models.Order, lastPrice decimal.Decimal) bool {
+	if order.Side == Buy {
+		return lastPrice.Cmp(order.StopPrice) >= 0
+	}
+	return lastPrice.Cmp(order.StopPrice) <= 0
+}
+
+// triggerStops activates the stop orders of ticker whose stop price has been
+// reached by the last trade, in arrival order. A stop becomes a market order
+// and a stop-limit a limit order. Since the trades of a triggered stop move
+// the last price again, triggering repeats until no further stop fires.
+// Stops stay untriggered while the ticker is halted.
+func triggerStops(tx *sql.Tx, ticker string) error {
+	for {
+		halted, err := IsHalted(tx, ticker)
+		if err != nil || halted {
+			return err
+		}
+
+		var lastPrice decimal.Decimal
+		err = tx.QueryRow(`SELECT price FROM stocks WHERE ticker = $1`, ticker).Scan(&lastPrice)
+		if err != nil {
+			return err
+		}
+
+		query := `
+			SELECT ` + orderColumns + `
+			FROM orders
+			WHERE ticker = $1 AND NOT triggered AND status = 'NEW'
+				AND (expires_at IS NULL OR expires_at > now())
+				AND ((side = 'BUY' AND stop_price <= $2) OR (side = 'SELL' AND stop_price >= $2))
+			ORDER BY priority ASC
+			LIMIT 1
+			FOR UPDATE`
+		order, err := scanOrder(tx.QueryRow(query, ticker, lastPrice))
+		if err == sql.ErrNoRows {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		order.Triggered = true
+		if _, err := execute(tx, order); err != nil {
+			return err
+		}
+	}
+}
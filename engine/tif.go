@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"stock_exchange_Golang_project/models"
+)
+
+const (
+	Day = "DAY"
+	GTC = "GTC"
+	IOC = "IOC"
+	FOK = "FOK"
+	GTD = "GTD"
+)
+
+// validateTimeInForce checks the time in force of order and, for DAY
+// orders, fixes their expiry at sessionClose. Only GTD orders carry a
+// caller-supplied expiry, which must lie in the future.
+func validateTimeInForce(order *models.Order, now, sessionClose time.Time) error {
+	switch order.TimeInForce {
+	case GTD:
+		if order.ExpiresAt == nil || !order.ExpiresAt.After(now) {
+			return ErrInvalidOrder
+		}
+	case Day:
+		if order.ExpiresAt != nil {
+			return ErrInvalidOrder
+		}
+		order.ExpiresAt = &sessionClose
+	case GTC, IOC, FOK:
+		if order.ExpiresAt != nil {
+			return ErrInvalidOrder
+		}
+	default:
+		return ErrInvalidOrder
+	}
+	return nil
+}
+
+// ExpireOrders marks every open order whose expiry has passed as EXPIRED and
+// returns how many were expired.
+func ExpireOrders(db *sql.DB, now time.Time) (int64, error) {
+	var expired int64
+	err := RunInTx(db, func(tx *sql.Tx) error {
+		query := `
+			UPDATE orders SET status = $1, updated_at = now()
+			WHERE status IN ('NEW', 'PARTIALLY_FILLED') AND expires_at IS NOT NULL AND expires_at <= $2
+			RETURNING ` + orderColumns
+		rows, err := tx.Query(query, StatusExpired, now)
+		if err != nil {
+			return err
+		}
+		var orders []*models.Order
+		for rows.Next() {
+			order, err := scanOrder(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			orders = append(orders, order)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		expired = int64(len(orders))
+		tickers := map[string]bool{}
+		for _, order := range orders {
+			if err := recordOrder(tx, order); err != nil {
+				return err
+			}
+			tickers[order.Ticker] = true
+		}
+		for ticker := range tickers {
+			if err := emitBook(tx, ticker); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return expired, err
+}
+
+// RunExpiry calls ExpireOrders every interval until the process exits.
+func RunExpiry(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		expired, err := ExpireOrders(db, now)
+		if err != nil {
+			log.Printf("Failed to expire orders: %v", err)
+			continue
+		}
+		if expired > 0 {
+			log.Printf("Expired %d orders", expired)
+		}
+	}
+}
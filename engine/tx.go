@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// maxAttempts bounds how many times RunInTx retries a transaction that lost
+// a serialization conflict or deadlock.
+const maxAttempts = 5
+
+// RunInTx runs fn inside a serializable transaction and commits it. When
+// Postgres aborts the transaction because of a concurrent update the whole
+// of fn is retried, so fn must not keep state between attempts. Events
+// raised by the engine inside fn are published after the commit.
+func RunInTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		err = runOnce(db, fn)
+		if !retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func runOnce(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	committed := false
+	defer func() { flush(tx, committed) }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// retryable reports whether err is a serialization failure or deadlock.
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
@@ -0,0 +1,217 @@
+package engine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"stock_exchange_Golang_project/config"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/models"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// openTestDB migrates a schema of its own in the database named by
+// TEST_DATABASE_URL, a lib/pq connection string that defaults to the
+// exchange's, and returns a connection that uses it. The schema is dropped
+// when the test ends. Tests are skipped when the database is unreachable.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	connStr := os.Getenv("TEST_DATABASE_URL")
+	if connStr == "" {
+		connStr = config.ConnectionString
+	}
+
+	admin, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Skipf("database unavailable: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	if err := admin.Ping(); err != nil {
+		t.Skipf("database unavailable: %v", err)
+	}
+
+	schema := fmt.Sprintf("engine_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("dropping %s: %v", schema, err)
+		}
+	})
+
+	db, err := sql.Open("postgres", connStr+" search_path="+schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := filepath.Glob("../migrations/*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(migrations)
+	for _, path := range migrations {
+		migration, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(migration)); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(path), err)
+		}
+	}
+
+	// Keep the market open around the clock so that orders are accepted
+	// whenever the test runs.
+	_, err = db.Exec(`
+		UPDATE market_calendar
+		SET timezone = 'UTC', pre_open_time = '00:00', open_time = '00:00', close_time = '23:59',
+			trading_days = '{0,1,2,3,4,5,6}'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// createTestUser opens a cash account for username funded with balance and
+// returns its ID.
+func createTestUser(t *testing.T, db *sql.DB, username string, balance decimal.Decimal) int {
+	t.Helper()
+	var userID int
+	err := RunInTx(db, func(tx *sql.Tx) error {
+		err := tx.QueryRow(`INSERT INTO users (username, account_type) VALUES ($1, $2) RETURNING id`,
+			username, CashAccount).Scan(&userID)
+		if err != nil {
+			return err
+		}
+		if err := ledger.OpenUserAccount(tx, userID); err != nil {
+			return err
+		}
+		return ledger.Transfer(tx, ledger.EntryDeposit, "Test funding", "", ledger.ExternalCashAccount,
+			ledger.UserAccount(userID), balance)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return userID
+}
+
+// TestConcurrentBuysNeverOverdraw sends many buys from one user at once,
+// each of which the user could afford on its own but not all together, and
+// checks that the serialized transactions never spend the same cash twice.
+func TestConcurrentBuysNeverOverdraw(t *testing.T) {
+	db := openTestDB(t)
+
+	const (
+		ticker  = "TEST"
+		buyers  = 16
+		perBuy  = 10
+		balance = "1000.00"
+	)
+	price := decimal.MustParse("10.00")
+
+	buyerID := createTestUser(t, db, "buyer", decimal.MustParse(balance))
+	sellerID := createTestUser(t, db, "seller", decimal.Zero)
+	err := RunInTx(db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO stocks (ticker, price, reference_price) VALUES ($1, $2, $2)`, ticker, price)
+		if err != nil {
+			return err
+		}
+		if err := ListStock(tx, ticker, price); err != nil {
+			return err
+		}
+		if err := IssueShares(tx, sellerID, ticker, buyers*perBuy, price); err != nil {
+			return err
+		}
+		ask := &models.Order{Ticker: ticker, Side: Sell, OrderType: Limit, TimeInForce: GTC, Price: price, Quantity: buyers * perBuy}
+		_, err = Submit(tx, sellerID, ask)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		start     = make(chan struct{})
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		spent     = decimal.Zero
+		notional  = decimal.Zero
+		filled    int
+		succeeded int
+	)
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			var order *models.Order
+			var fills []Fill
+			err := RunInTx(db, func(tx *sql.Tx) error {
+				order = &models.Order{Ticker: ticker, Side: Buy, OrderType: Limit, TimeInForce: IOC, Price: price, Quantity: perBuy}
+				var err error
+				fills, err = Submit(tx, buyerID, order)
+				return err
+			})
+			switch {
+			case err == nil:
+				mu.Lock()
+				defer mu.Unlock()
+				succeeded++
+				filled += order.FilledQuantity
+				for _, fill := range fills {
+					notional = notional.Add(fill.Notional())
+					spent = spent.Add(fill.Notional()).Add(fill.BuyerFee)
+				}
+			// A buy that ran out of retries is rolled back as a whole; the
+			// checks below show it left no order, shares or debit behind.
+			case errors.Is(err, ErrInsufficientBalance), retryable(err):
+			default:
+				t.Errorf("buy failed: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	cash, err := ledger.CashBalance(db, buyerID, fx.Base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cash.IsNegative() {
+		t.Errorf("buyer balance went negative: %s", cash)
+	}
+	if want := decimal.MustParse(balance).Sub(spent); !cash.Equal(want) {
+		t.Errorf("buyer balance = %s, want %s after spending %s", cash, want, spent)
+	}
+	if want := price.MulInt(filled); !notional.Equal(want) {
+		t.Errorf("buyer paid %s for %d shares, want %s", notional, filled, want)
+	}
+
+	var shares int
+	err = db.QueryRow(`SELECT COALESCE(SUM(quantity), 0) FROM positions WHERE user_id = $1 AND ticker = $2`, buyerID, ticker).Scan(&shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shares != filled {
+		t.Errorf("buyer holds %d shares, want %d", shares, filled)
+	}
+
+	var orders, ordered int
+	err = db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(filled_quantity), 0) FROM orders WHERE user_id = $1`, buyerID).Scan(&orders, &ordered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orders != succeeded || ordered != filled {
+		t.Errorf("buyer has %d orders filling %d shares, want %d filling %d", orders, ordered, succeeded, filled)
+	}
+}
@@ -0,0 +1,89 @@
+// Package events is an in-process publish/subscribe bus for exchange
+// activity such as trades, order updates and trading halts.
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	Halt            = "HALT"
+	CorporateAction = "CORPORATE_ACTION"
+	Settlement      = "SETTLEMENT"
+	Trade           = "TRADE"
+	Book            = "BOOK"
+)
+
+// Event is a single notification. UserID is set for events that concern
+// one user only; Ticker for events that concern one instrument.
+type Event struct {
+	Type   string      `json:"type"`
+	Ticker string      `json:"ticker,omitempty"`
+	UserID int         `json:"user_id,omitempty"`
+	Data   interface{} `json:"data"`
+	Time   time.Time   `json:"time"`
+}
+
+// Subscription receives published events on C until it is unsubscribed.
+type Subscription struct {
+	C <-chan Event
+
+	ch   chan Event
+	lost atomic.Bool
+	once sync.Once
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers = map[*Subscription]struct{}{}
+)
+
+// Subscribe registers a new subscriber whose channel buffers up to size
+// events.
+func Subscribe(size int) *Subscription {
+	ch := make(chan Event, size)
+	sub := &Subscription{C: ch, ch: ch}
+
+	mu.Lock()
+	subscribers[sub] = struct{}{}
+	mu.Unlock()
+
+	return sub
+}
+
+// Unsubscribe stops delivery to sub and closes its channel.
+func (sub *Subscription) Unsubscribe() {
+	sub.once.Do(func() {
+		mu.Lock()
+		delete(subscribers, sub)
+		mu.Unlock()
+		close(sub.ch)
+	})
+}
+
+// Lost reports whether sub has missed any event since Lost was last called.
+func (sub *Subscription) Lost() bool {
+	return sub.lost.Swap(false)
+}
+
+// Publish delivers event to every subscriber. Publishing never blocks: a
+// subscriber whose buffer is full misses the event, which its Lost method
+// then reports.
+func Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for sub := range subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.lost.Store(true)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package feed keeps the private event feed of every user: updates to their
+// orders, their fills and changes to their cash balances. Events are stored
+// in the transaction that causes them, so the feed holds exactly what was
+// committed, and are numbered per user in commit order so that a reader can
+// resume after the last event it saw. Committed events are announced with a
+// Postgres notification that wakes up the readers of the user's feed.
+package feed
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	Order   = "ORDER"
+	Fill    = "FILL"
+	Balance = "BALANCE"
+)
+
+// channel is the Postgres notification channel committed events are
+// announced on. The payload is the user ID.
+const channel = "user_events"
+
+// Querier is satisfied by both *sql.DB and *sql.Tx.
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Event is one entry of a user's feed. Seq numbers the user's events
+// consecutively from 1.
+type Event struct {
+	Seq       int64           `json:"seq"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Record appends an event to the feed of userID. The user row is locked
+// until tx ends, so concurrent writers number their events in the order they
+// commit.
+func Record(tx *sql.Tx, userID int, eventType string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var seq int64
+	err = tx.QueryRow(`UPDATE users SET event_seq = event_seq + 1 WHERE id = $1 RETURNING event_seq`, userID).Scan(&seq)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO user_events (user_id, seq, event_type, data) VALUES ($1, $2, $3, $4)`,
+		userID, seq, eventType, encoded)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`SELECT pg_notify($1, $2)`, channel, strconv.Itoa(userID))
+	return err
+}
+
+// LastSeq returns the number of the latest event in the feed of userID.
+func LastSeq(q Querier, userID int) (int64, error) {
+	var seq int64
+	err := q.QueryRow(`SELECT event_seq FROM users WHERE id = $1`, userID).Scan(&seq)
+	return seq, err
+}
+
+// Since returns up to limit events of userID numbered after seq, oldest
+// first.
+func Since(q Querier, userID int, seq int64, limit int) ([]Event, error) {
+	query := `
+		SELECT seq, event_type, data, created_at
+		FROM user_events
+		WHERE user_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3`
+	rows, err := q.Query(query, userID, seq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(&event.Seq, &event.Type, &event.Data, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+var (
+	mu      sync.Mutex
+	readers = map[int]map[chan struct{}]struct{}{}
+)
+
+// Subscribe registers a reader of the feed of userID. The returned channel
+// receives a value whenever new events may have been committed; readers
+// then fetch them with Since. The returned function unsubscribes.
+func Subscribe(userID int) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	mu.Lock()
+	if readers[userID] == nil {
+		readers[userID] = map[chan struct{}]struct{}{}
+	}
+	readers[userID][ch] = struct{}{}
+	mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			mu.Lock()
+			delete(readers[userID], ch)
+			if len(readers[userID]) == 0 {
+				delete(readers, userID)
+			}
+			mu.Unlock()
+		})
+	}
+}
+
+// wake signals the readers of userID, or of every feed when userID is zero.
+// A reader already signalled is not signalled twice.
+func wake(userID int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for id, chans := range readers {
+		if userID != 0 && id != userID {
+			continue
+		}
+		for ch := range chans {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Listen waits for committed events on the database at connStr and wakes
+// their readers until the process exits. After the connection is lost and
+// re-established every reader is woken, since notifications may have been
+// missed in between.
+func Listen(connStr string) {
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("User event listener: %v", err)
+		}
+	})
+	if err := listener.Listen(channel); err != nil {
+		log.Printf("Failed to listen for user events: %v", err)
+		return
+	}
+
+	for notification := range listener.Notify {
+		if notification == nil {
+			wake(0)
+			continue
+		}
+		userID, err := strconv.Atoi(notification.Extra)
+		if err != nil {
+			continue
+		}
+		wake(userID)
+	}
+}
@@ -0,0 +1,118 @@
+// Package fx keeps the exchange rates between the exchange's base currency
+// and the other currencies stocks trade in. Cash balances, buying power and
+// account values are reported in the base currency; cash held in another
+// currency is valued at the rate configured for it.
+package fx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// Base is the currency of users' main cash balances and of every stock that
+// does not declare another one.
+const Base = "USD"
+
+var (
+	ErrInvalidCurrency = errors.New("currency must be a three-letter ISO 4217 code")
+	ErrInvalidRate     = errors.New("exchange rate must be positive")
+	ErrNoRate          = errors.New("no exchange rate configured")
+)
+
+// Querier is satisfied by both *sql.DB and *sql.Tx.
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Rate is the price of one unit of Currency in the base currency.
+type Rate struct {
+	Currency  string          `json:"currency" example:"EUR"`
+	Rate      decimal.Decimal `json:"rate" example:"1.0825"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 currency code.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Load returns the rate of currency. The rate of the base currency is
+// always 1.
+func Load(q Querier, currency string) (decimal.Decimal, error) {
+	if currency == Base {
+		return decimal.FromInt(1), nil
+	}
+	var rate decimal.Decimal
+	err := q.QueryRow(`SELECT rate FROM fx_rates WHERE currency = $1`, currency).Scan(&rate)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, fmt.Errorf("%w for %s", ErrNoRate, currency)
+	}
+	return rate, err
+}
+
+// List returns every configured rate ordered by currency.
+func List(q Querier) ([]Rate, error) {
+	rows, err := q.Query(`SELECT currency, rate, updated_at FROM fx_rates ORDER BY currency`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []Rate{}
+	for rows.Next() {
+		var rate Rate
+		if err := rows.Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+// Set creates or replaces the rate of currency. The base currency has no
+// configurable rate.
+func Set(q Querier, currency string, rate decimal.Decimal) (*Rate, error) {
+	if !ValidCurrency(currency) || currency == Base {
+		return nil, ErrInvalidCurrency
+	}
+	if !rate.IsPositive() {
+		return nil, ErrInvalidRate
+	}
+
+	saved := Rate{Currency: currency}
+	query := `
+		INSERT INTO fx_rates (currency, rate)
+		VALUES ($1, $2)
+		ON CONFLICT (currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = now()
+		RETURNING rate, updated_at`
+	if err := q.QueryRow(query, currency, rate).Scan(&saved.Rate, &saved.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// ToBase returns the base-currency value of amount at rate, rounded to the
+// cent with mode.
+func ToBase(amount, rate decimal.Decimal, mode decimal.RoundingMode) decimal.Decimal {
+	return amount.Mul(rate).Round(decimal.Cents, mode)
+}
+
+// FromBase returns how much of a currency with the given rate an amount of
+// base currency buys, rounded to the cent with mode.
+func FromBase(amount, rate decimal.Decimal, mode decimal.RoundingMode) decimal.Decimal {
+	return amount.Div(rate, mode).Round(decimal.Cents, mode)
+}
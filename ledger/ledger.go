@@ -0,0 +1,446 @@
+// Package ledger keeps the double-entry journal of every cash movement on
+// the exchange. Each user has a cash account and the exchange holds system
+// accounts such as its fee revenue. Money only moves through journal
+// entries whose postings sum to zero in every currency, and the balance
+// columns of users, cash_balances and exchange_accounts are maintained from
+// those postings.
+//
+// Accounts hold a single currency. The named accounts hold the base
+// currency; their counterparts in another currency are opened on demand by
+// CurrencyAccount.
+//
+// Besides their cash account, users have an unsettled account holding the
+// cash they have received from trades that have not settled yet. It is not
+// part of their balance and moves to their cash account when the trades
+// settle.
+package ledger
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"stock_exchange_Golang_project/feed"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	// FeeRevenueAccount is credited with every commission.
+	FeeRevenueAccount = "FEE_REVENUE"
+	// ExternalCashAccount is the counterpart of money entering or leaving
+	// the exchange, such as deposits and withdrawals.
+	ExternalCashAccount = "EXTERNAL_CASH"
+	// PendingWithdrawalsAccount holds the cash of withdrawals that have
+	// been requested but not yet paid out.
+	PendingWithdrawalsAccount = "PENDING_WITHDRAWALS"
+	// FXConversionAccount is the exchange's side of every currency
+	// conversion.
+	FXConversionAccount = "FX_CONVERSION"
+	// ReconciliationAccount is the counterpart of the corrections posted by
+	// reconciliation.
+	ReconciliationAccount = "RECONCILIATION"
+)
+
+const (
+	EntryOpeningBalance  = "OPENING_BALANCE"
+	EntryTrade           = "TRADE"
+	EntryFee             = "FEE"
+	EntryDeposit         = "DEPOSIT"
+	EntryWithdrawal      = "WITHDRAWAL"
+	EntryConversion      = "FX"
+	EntryDividend        = "DIVIDEND"
+	EntryCashInLieu      = "CASH_IN_LIEU"
+	EntryTradeAdjustment = "TRADE_ADJUSTMENT"
+	EntrySettlement      = "SETTLEMENT"
+)
+
+var (
+	ErrUnbalanced     = errors.New("journal entry does not balance")
+	ErrUnknownAccount = errors.New("ledger account not found")
+)
+
+// Querier is satisfied by both *sql.DB and *sql.Tx.
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// BalanceChange is the feed event of a posting to a user's cash: Amount
+// moved into the account in Currency, leaving Balance.
+type BalanceChange struct {
+	EntryID     int             `json:"entry_id"`
+	EntryType   string          `json:"entry_type"`
+	Description string          `json:"description"`
+	Currency    string          `json:"currency"`
+	Amount      decimal.Decimal `json:"amount"`
+	Balance     decimal.Decimal `json:"balance"`
+}
+
+// Posting moves Amount into the named account; negative amounts move money
+// out of it.
+type Posting struct {
+	Account string          `json:"account"`
+	Amount  decimal.Decimal `json:"amount"`
+}
+
+// Entry is a single balanced journal entry. Reference ties the entry to the
+// record that caused it, such as the order of a trade.
+type Entry struct {
+	ID          int       `json:"id"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Reference   string    `json:"reference,omitempty"`
+	Postings    []Posting `json:"postings"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Cash is the balance a user holds in one currency.
+type Cash struct {
+	Currency string          `json:"currency" example:"EUR"`
+	Balance  decimal.Decimal `json:"balance" example:"250.00"`
+}
+
+// Line is one posting to a user's cash account as it appears on their
+// statement, with the account balance after it.
+type Line struct {
+	EntryID     int             `json:"entry_id"`
+	Type        string          `json:"type"`
+	Description string          `json:"description"`
+	Reference   string          `json:"reference,omitempty"`
+	Amount      decimal.Decimal `json:"amount"`
+	Balance     decimal.Decimal `json:"balance"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// UserAccount returns the name of the cash account of a user.
+func UserAccount(userID int) string {
+	return fmt.Sprintf("USER:%d", userID)
+}
+
+// UnsettledAccount returns the name of the unsettled account of a user.
+func UnsettledAccount(userID int) string {
+	return fmt.Sprintf("USER:%d:UNSETTLED", userID)
+}
+
+// OpenUserAccount creates the cash and unsettled accounts of a user if they
+// do not exist.
+func OpenUserAccount(tx *sql.Tx, userID int) error {
+	query := `
+		INSERT INTO ledger_accounts (name, user_id, unsettled) VALUES ($1, $3, FALSE), ($2, $3, TRUE)
+		ON CONFLICT (name) DO NOTHING`
+	_, err := tx.Exec(query, UserAccount(userID), UnsettledAccount(userID), userID)
+	return err
+}
+
+// CurrencyAccount returns the name of the account holding currency for the
+// owner of account, opening it if needed. The base currency is held in
+// account itself.
+func CurrencyAccount(tx *sql.Tx, account, currency string) (string, error) {
+	if currency == fx.Base {
+		return account, nil
+	}
+	name := account + ":" + currency
+	query := `
+		INSERT INTO ledger_accounts (name, user_id, currency, unsettled)
+		SELECT $1, user_id, $2, unsettled FROM ledger_accounts WHERE name = $3
+		ON CONFLICT (name) DO NOTHING`
+	if _, err := tx.Exec(query, name, currency, account); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ledgerAccount is the stored side of an account a posting moves.
+type ledgerAccount struct {
+	id        int
+	userID    sql.NullInt64
+	currency  string
+	unsettled bool
+}
+
+// Post records entry and applies its postings to the cached account
+// balances, adding a balance event to the feed of every user whose cash it
+// moves. Unsettled accounts have no cached balance. Postings of zero are
+// dropped; the rest must sum to zero in each currency. The entry is updated
+// in place with its ID and creation time.
+func Post(tx *sql.Tx, entry *Entry) error {
+	var postings []Posting
+	var accounts []ledgerAccount
+	totals := map[string]decimal.Decimal{}
+	for _, posting := range entry.Postings {
+		if posting.Amount.IsZero() {
+			continue
+		}
+		var account ledgerAccount
+		err := tx.QueryRow(`SELECT id, user_id, currency, unsettled FROM ledger_accounts WHERE name = $1`, posting.Account).Scan(&account.id, &account.userID, &account.currency, &account.unsettled)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %s", ErrUnknownAccount, posting.Account)
+		} else if err != nil {
+			return err
+		}
+		postings = append(postings, posting)
+		accounts = append(accounts, account)
+		totals[account.currency] = totals[account.currency].Add(posting.Amount)
+	}
+	for currency, total := range totals {
+		if !total.IsZero() {
+			return fmt.Errorf("%w: %s %q is off by %s %s", ErrUnbalanced, entry.Type, entry.Description, total, currency)
+		}
+	}
+	entry.Postings = postings
+	if len(postings) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO journal_entries (entry_type, description, reference)
+		VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING id, created_at`
+	err := tx.QueryRow(query, entry.Type, entry.Description, entry.Reference).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i, posting := range postings {
+		account := accounts[i]
+		_, err = tx.Exec(`INSERT INTO postings (entry_id, account_id, amount) VALUES ($1, $2, $3)`, entry.ID, account.id, posting.Amount)
+		if err != nil {
+			return err
+		}
+
+		var balance decimal.Decimal
+		switch {
+		case account.unsettled:
+			continue
+		case account.userID.Valid && account.currency == fx.Base:
+			err = tx.QueryRow(`UPDATE users SET balance = balance + $1 WHERE id = $2 RETURNING balance`, posting.Amount, account.userID.Int64).Scan(&balance)
+		case account.userID.Valid:
+			query := `
+				INSERT INTO cash_balances (user_id, currency, balance)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (user_id, currency) DO UPDATE SET balance = cash_balances.balance + EXCLUDED.balance
+				RETURNING balance`
+			err = tx.QueryRow(query, account.userID.Int64, account.currency, posting.Amount).Scan(&balance)
+		default:
+			query := `
+				INSERT INTO exchange_accounts (name, balance)
+				VALUES ($1, $2)
+				ON CONFLICT (name) DO UPDATE SET balance = exchange_accounts.balance + EXCLUDED.balance`
+			_, err = tx.Exec(query, posting.Account, posting.Amount)
+		}
+		if err != nil {
+			return err
+		}
+
+		if account.userID.Valid {
+			err := feed.Record(tx, int(account.userID.Int64), feed.Balance, BalanceChange{
+				EntryID:     entry.ID,
+				EntryType:   entry.Type,
+				Description: entry.Description,
+				Currency:    account.currency,
+				Amount:      posting.Amount,
+				Balance:     balance,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Transfer posts an entry moving amount from one account to another.
+func Transfer(tx *sql.Tx, entryType, description, reference, from, to string, amount decimal.Decimal) error {
+	return Post(tx, &Entry{
+		Type:        entryType,
+		Description: description,
+		Reference:   reference,
+		Postings: []Posting{
+			{Account: from, Amount: amount.Neg()},
+			{Account: to, Amount: amount},
+		},
+	})
+}
+
+// Convert posts an entry exchanging fromAmount of one currency held in
+// account for toAmount of another, with the exchange's conversion account
+// on the other side.
+func Convert(tx *sql.Tx, description, reference, account, from string, fromAmount decimal.Decimal, to string, toAmount decimal.Decimal) error {
+	var postings []Posting
+	for _, leg := range []struct {
+		currency string
+		amount   decimal.Decimal
+	}{{from, fromAmount.Neg()}, {to, toAmount}} {
+		owner, err := CurrencyAccount(tx, account, leg.currency)
+		if err != nil {
+			return err
+		}
+		exchange, err := CurrencyAccount(tx, FXConversionAccount, leg.currency)
+		if err != nil {
+			return err
+		}
+		postings = append(postings, Posting{Account: owner, Amount: leg.amount}, Posting{Account: exchange, Amount: leg.amount.Neg()})
+	}
+
+	return Post(tx, &Entry{Type: EntryConversion, Description: description, Reference: reference, Postings: postings})
+}
+
+// Balance returns the balance of the named account as the sum of its
+// postings.
+func Balance(q Querier, account string) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	query := `
+		SELECT COALESCE(SUM(p.amount), 0)
+		FROM ledger_accounts a
+		LEFT JOIN postings p ON p.account_id = a.id
+		WHERE a.name = $1
+		GROUP BY a.id`
+	err := q.QueryRow(query, account).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrUnknownAccount, account)
+	}
+	return balance, err
+}
+
+// CashBalance returns the cash a user holds in currency.
+func CashBalance(q Querier, userID int, currency string) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	if currency == fx.Base {
+		err := q.QueryRow(`SELECT balance FROM users WHERE id = $1`, userID).Scan(&balance)
+		return balance, err
+	}
+	query := `SELECT COALESCE((SELECT balance FROM cash_balances WHERE user_id = $1 AND currency = $2), 0)`
+	err := q.QueryRow(query, userID, currency).Scan(&balance)
+	return balance, err
+}
+
+// UnsettledBalance returns the cash a user has received in currency from
+// trades that have not settled yet.
+func UnsettledBalance(q Querier, userID int, currency string) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	query := `
+		SELECT COALESCE(SUM(p.amount), 0)
+		FROM ledger_accounts a
+		INNER JOIN postings p ON p.account_id = a.id
+		WHERE a.user_id = $1 AND a.currency = $2 AND a.unsettled`
+	err := q.QueryRow(query, userID, currency).Scan(&balance)
+	return balance, err
+}
+
+// UnsettledBalances returns the cash a user has received from trades that
+// have not settled yet in each currency with a non-zero balance.
+func UnsettledBalances(q Querier, userID int) ([]Cash, error) {
+	query := `
+		SELECT a.currency, SUM(p.amount)
+		FROM ledger_accounts a
+		INNER JOIN postings p ON p.account_id = a.id
+		WHERE a.user_id = $1 AND a.unsettled
+		GROUP BY a.currency
+		HAVING SUM(p.amount) <> 0
+		ORDER BY a.currency`
+	rows, err := q.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []Cash{}
+	for rows.Next() {
+		var cash Cash
+		if err := rows.Scan(&cash.Currency, &cash.Balance); err != nil {
+			return nil, err
+		}
+		balances = append(balances, cash)
+	}
+
+	return balances, rows.Err()
+}
+
+// CashBalances returns the cash a user holds in each currency, the base
+// currency first and then every other currency with a non-zero balance.
+func CashBalances(q Querier, userID int) ([]Cash, error) {
+	query := `
+		SELECT currency, balance FROM (
+			SELECT $2::varchar AS currency, balance FROM users WHERE id = $1
+			UNION ALL
+			SELECT currency, balance FROM cash_balances WHERE user_id = $1 AND balance <> 0
+		) cash
+		ORDER BY currency <> $2, currency`
+	rows, err := q.Query(query, userID, fx.Base)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []Cash{}
+	for rows.Next() {
+		var cash Cash
+		if err := rows.Scan(&cash.Currency, &cash.Balance); err != nil {
+			return nil, err
+		}
+		balances = append(balances, cash)
+	}
+
+	return balances, rows.Err()
+}
+
+// AccountBalances returns the balance of an exchange account in each
+// currency it holds, the base currency first and then every other currency
+// opened by CurrencyAccount.
+func AccountBalances(q Querier, account string) ([]Cash, error) {
+	query := `
+		SELECT a.currency, COALESCE(x.balance, 0)
+		FROM ledger_accounts a
+		LEFT JOIN exchange_accounts x ON x.name = a.name
+		WHERE a.user_id IS NULL AND (a.name = $1 OR a.name = $1 || ':' || a.currency)
+		ORDER BY a.currency <> $2, a.currency`
+	rows, err := q.Query(query, account, fx.Base)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []Cash{}
+	for rows.Next() {
+		var cash Cash
+		if err := rows.Scan(&cash.Currency, &cash.Balance); err != nil {
+			return nil, err
+		}
+		balances = append(balances, cash)
+	}
+
+	return balances, rows.Err()
+}
+
+// Statement returns the postings to a user's cash account in currency,
+// newest first, each with the balance of the account once it was applied.
+func Statement(q Querier, userID int, currency string) ([]Line, error) {
+	query := `
+		SELECT e.id, e.entry_type, e.description, COALESCE(e.reference, ''), p.amount,
+			SUM(p.amount) OVER (ORDER BY p.id), e.created_at
+		FROM postings p
+		INNER JOIN ledger_accounts a ON a.id = p.account_id
+		INNER JOIN journal_entries e ON e.id = p.entry_id
+		WHERE a.user_id = $1 AND a.currency = $2 AND NOT a.unsettled
+		ORDER BY p.id DESC`
+	rows, err := q.Query(query, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []Line{}
+	for rows.Next() {
+		var line Line
+		err := rows.Scan(&line.EntryID, &line.Type, &line.Description, &line.Reference, &line.Amount, &line.Balance, &line.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"stock_exchange_Golang_project/config"
 	_ "stock_exchange_Golang_project/docs"
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/feed"
+	"stock_exchange_Golang_project/marketdata"
+	"stock_exchange_Golang_project/payments"
 	"stock_exchange_Golang_project/routes"
+	"stock_exchange_Golang_project/stream"
+	"strings"
+	"time"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -19,6 +28,44 @@ import (
 // @host localhost:8080
 // @BasePath /api
 func main() {
+	replay := flag.String("replay", "", "comma-separated CSV files of historical ticks to replay into stock prices")
+	replaySpeed := flag.Float64("replay-speed", 1, "replay speed as a multiple of real time; 0 replays as fast as possible")
+	simulate := flag.Bool("simulate", false, "move the prices of all listed stocks by geometric Brownian motion")
+	var simulator marketdata.SimulatorConfig
+	flag.Float64Var(&simulator.Drift, "sim-drift", 0.05, "annualized drift of simulated prices")
+	flag.Float64Var(&simulator.Volatility, "sim-volatility", 0.3, "annualized volatility of simulated prices")
+	flag.Int64Var(&simulator.Seed, "sim-seed", 0, "seed of simulated prices; 0 seeds from the clock")
+	flag.DurationVar(&simulator.Interval, "sim-interval", time.Second, "time between simulated price steps")
+	fakePayments := flag.Bool("fake-payments", false, "settle approved deposits and withdrawals in-process; for tests and sandboxes only")
+	flag.Parse()
+
+	go engine.RunExpiry(config.ConnectDB(), time.Minute)
+	go engine.RunCorporateActions(config.ConnectDB(), time.Minute)
+	go engine.RunSettlement(config.ConnectDB(), time.Minute)
+	go stream.Run(config.ConnectDB())
+	go feed.Listen(config.ConnectionString)
+
+	// No real payment rail is integrated yet. Without the fake rail approved
+	// transfers stay APPROVED until one is configured.
+	if *fakePayments {
+		payments.UseRail(payments.NewFakeRail())
+	}
+
+	// Without a market data provider prices only move with trades.
+	if *replay != "" {
+		provider, err := marketdata.NewCSVReplay(strings.Split(*replay, ","), *replaySpeed)
+		if err != nil {
+			log.Fatalf("Failed to load market data replay: %v", err)
+		}
+		go marketdata.Run(config.ConnectDB(), provider)
+	}
+	if *simulate {
+		provider, err := marketdata.NewSimulator(config.ConnectDB(), simulator)
+		if err != nil {
+			log.Fatalf("Failed to start price simulator: %v", err)
+		}
+		go marketdata.Run(config.ConnectDB(), provider)
+	}
 
 	router := routes.ConfigureRoutes()
 
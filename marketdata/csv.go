@@ -0,0 +1,131 @@
+package marketdata
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// timeLayouts are the timestamp formats CSV files may use. Timestamps
+// without a zone are in UTC.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+// CSVReplay replays historical ticks from CSV files. Each file starts with
+// a header naming its columns: timestamp, ticker and price are required and
+// volume is optional. The ticks of all files are merged in timestamp order
+// and replayed with the gaps between them divided by the speed, so a speed
+// of 60 plays an hour of history in a minute; a speed of 0 replays them as
+// fast as they can be applied. Replayed ticks are stamped with the time they
+// are replayed at.
+type CSVReplay struct {
+	speed float64
+	ticks []Tick
+}
+
+// NewCSVReplay reads every tick of the files at paths.
+func NewCSVReplay(paths []string, speed float64) (*CSVReplay, error) {
+	if speed < 0 {
+		return nil, errors.New("replay speed must not be negative")
+	}
+	replay := &CSVReplay{speed: speed}
+	for _, path := range paths {
+		ticks, err := readTicks(path)
+		if err != nil {
+			return nil, err
+		}
+		replay.ticks = append(replay.ticks, ticks...)
+	}
+	sort.SliceStable(replay.ticks, func(i, j int) bool {
+		return replay.ticks[i].Time.Before(replay.ticks[j].Time)
+	})
+	return replay, nil
+}
+
+func (replay *CSVReplay) Name() string {
+	return "csv-replay"
+}
+
+func (replay *CSVReplay) Stream(handle func(Tick) error) error {
+	if len(replay.ticks) == 0 {
+		return nil
+	}
+	first, start := replay.ticks[0].Time, time.Now()
+	for _, tick := range replay.ticks {
+		if replay.speed > 0 {
+			due := start.Add(time.Duration(float64(tick.Time.Sub(first)) / replay.speed))
+			time.Sleep(time.Until(due))
+		}
+		tick.Time = time.Now()
+		if err := handle(tick); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTicks parses the ticks of one CSV file.
+func readTicks(path string) ([]Tick, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: reading header: %w", path, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"timestamp", "ticker", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%s: missing %s column", path, name)
+		}
+	}
+
+	var ticks []Tick
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return ticks, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		tick := Tick{Ticker: record[columns["ticker"]]}
+		if tick.Time, err = parseTimestamp(record[columns["timestamp"]]); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if tick.Price, err = decimal.Parse(record[columns["price"]]); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid price %q", path, line, record[columns["price"]])
+		}
+		if i, ok := columns["volume"]; ok && record[i] != "" {
+			if tick.Volume, err = strconv.Atoi(record[i]); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid volume %q", path, line, record[i])
+			}
+		}
+		ticks = append(ticks, tick)
+	}
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
@@ -0,0 +1,70 @@
+// Package marketdata feeds prices from outside sources into the exchange.
+// A Provider produces ticks; Run applies each one as the last price of its
+// stock, records it in the price history and triggers the stop orders it
+// reaches or trips the circuit breaker, just as a trade on the exchange
+// would.
+package marketdata
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// Tick is one price observation of a ticker. Volume is the number of shares
+// the source reported traded at Price, or zero for a quote.
+type Tick struct {
+	Ticker string
+	Price  decimal.Decimal
+	Volume int
+	Time   time.Time
+}
+
+// Provider is a source of market data.
+type Provider interface {
+	// Name identifies the provider in logs.
+	Name() string
+	// Stream calls handle with every tick, in the order they occur, until
+	// the source is exhausted or fails. Ticks are handled one at a time, so
+	// a slow handler slows the provider down rather than losing ticks.
+	Stream(handle func(Tick) error) error
+}
+
+// Run feeds every tick of provider into the exchange until the provider
+// stops. Prices are rounded half to even to the cent. Ticks that cannot be
+// applied, such as those of unknown or delisted tickers or outside the
+// ticker's price bands, are logged and skipped.
+func Run(db *sql.DB, provider Provider) {
+	applied, skipped := 0, 0
+	err := provider.Stream(func(tick Tick) error {
+		if err := apply(db, tick); err != nil {
+			log.Printf("Skipped %s tick of %s at %s: %v", provider.Name(), tick.Ticker, tick.Price, err)
+			skipped++
+			return nil
+		}
+		applied++
+		return nil
+	})
+	if err != nil {
+		log.Printf("Market data provider %s failed: %v", provider.Name(), err)
+	}
+	log.Printf("Market data provider %s stopped after %d ticks, %d skipped", provider.Name(), applied, skipped)
+}
+
+func apply(db *sql.DB, tick Tick) error {
+	if tick.Volume < 0 {
+		return errors.New("negative volume")
+	}
+	price := tick.Price.Round(decimal.Cents, decimal.HalfEven)
+	at := tick.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return engine.RunInTx(db, func(tx *sql.Tx) error {
+		return engine.SetMarketPrice(tx, tick.Ticker, price, tick.Volume, at)
+	})
+}
@@ -0,0 +1,153 @@
+package marketdata
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+// secondsPerYear converts tick intervals to the years drift and volatility
+// are quoted in.
+const secondsPerYear = 365.25 * 24 * 60 * 60
+
+// minPrice and maxPrice bound simulated prices to a cent and the largest
+// price the stocks table holds.
+const (
+	minPrice = 0.01
+	maxPrice = 99999999.99
+)
+
+// SimulatorConfig sets how simulated prices move. Drift and Volatility are
+// annualized, as the expected log return per year and its standard
+// deviation. A zero Seed seeds the simulator from the clock.
+type SimulatorConfig struct {
+	Drift      float64
+	Volatility float64
+	Seed       int64
+	Interval   time.Duration
+}
+
+// Simulator moves the price of every listed stock on its own by geometric
+// Brownian motion, for sandboxes, demos and load tests. Every interval each
+// price takes one step
+//
+//	S' = S * exp((drift - volatility²/2) * dt + volatility * sqrt(dt) * Z)
+//
+// with dt the interval in years and Z standard normal. Prices are kept
+// unrounded between steps, so that moves smaller than a cent add up, but a
+// stock whose price changed by other means, such as a trade, continues from
+// its new price. Ticks are only sent when the price in cents changes. A step
+// that overflows, as large volatilities or intervals can make it, is
+// skipped, and prices are kept between minPrice and maxPrice. With the same
+// seed and stocks the simulator takes the same steps.
+type Simulator struct {
+	db     *sql.DB
+	config SimulatorConfig
+	rand   *rand.Rand
+	prices map[string]simulatedPrice
+}
+
+// simulatedPrice is the unrounded price of a stock and the price in cents
+// last sent for it.
+type simulatedPrice struct {
+	exact float64
+	sent  decimal.Decimal
+}
+
+// NewSimulator returns a simulator of the stocks listed in db.
+func NewSimulator(db *sql.DB, config SimulatorConfig) (*Simulator, error) {
+	if config.Volatility < 0 || config.Interval <= 0 {
+		return nil, errors.New("simulator needs a non-negative volatility and a positive interval")
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Simulator{
+		db:     db,
+		config: config,
+		rand:   rand.New(rand.NewSource(seed)),
+		prices: map[string]simulatedPrice{},
+	}, nil
+}
+
+func (sim *Simulator) Name() string {
+	return "simulator"
+}
+
+// Stream steps every listed stock once per interval until handle fails.
+func (sim *Simulator) Stream(handle func(Tick) error) error {
+	interval := time.NewTicker(sim.config.Interval)
+	defer interval.Stop()
+
+	dt := sim.config.Interval.Seconds() / secondsPerYear
+	drift := (sim.config.Drift - sim.config.Volatility*sim.config.Volatility/2) * dt
+	diffusion := sim.config.Volatility * math.Sqrt(dt)
+
+	for now := range interval.C {
+		stocks, err := listedPrices(sim.db)
+		if err != nil {
+			log.Printf("Simulator failed to load stocks: %v", err)
+			continue
+		}
+
+		for _, stock := range stocks {
+			current, ok := sim.prices[stock.ticker]
+			if !ok || !current.sent.Equal(stock.price) {
+				exact, _ := strconv.ParseFloat(stock.price.String(), 64)
+				current = simulatedPrice{exact: exact, sent: stock.price}
+			}
+
+			next := current.exact * math.Exp(drift+diffusion*sim.rand.NormFloat64())
+			if math.IsNaN(next) || math.IsInf(next, 0) {
+				continue
+			}
+			current.exact = math.Min(math.Max(next, minPrice), maxPrice)
+			price, err := decimal.Parse(strconv.FormatFloat(current.exact, 'f', decimal.Scale, 64))
+			if err != nil {
+				log.Printf("Simulator skipped a step of %s to %v: %v", stock.ticker, current.exact, err)
+				continue
+			}
+			price = price.Round(decimal.Cents, decimal.HalfEven)
+
+			if !price.Equal(current.sent) {
+				if err := handle(Tick{Ticker: stock.ticker, Price: price, Time: now}); err != nil {
+					return err
+				}
+				current.sent = price
+			}
+			sim.prices[stock.ticker] = current
+		}
+	}
+	return nil
+}
+
+type listedPrice struct {
+	ticker string
+	price  decimal.Decimal
+}
+
+// listedPrices returns the last price of every listed stock, by ticker.
+func listedPrices(db *sql.DB) ([]listedPrice, error) {
+	rows, err := db.Query(`SELECT ticker, price FROM stocks WHERE delisted_at IS NULL ORDER BY ticker`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocks []listedPrice
+	for rows.Next() {
+		var stock listedPrice
+		if err := rows.Scan(&stock.ticker, &stock.price); err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, stock)
+	}
+	return stocks, rows.Err()
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"database/sql"
+	"net/http"
+	"stock_exchange_Golang_project/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware only lets through auth users flagged as administrators.
+// It must run after AuthMiddleware, which identifies the caller.
+func AdminMiddleware(c *gin.Context) {
+	db := config.ConnectDB()
+	defer db.Close()
+
+	isAdmin, err := IsAdmin(db, c.GetString("username"))
+	if err != nil || !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+// IsAdmin reports whether the auth user username is an administrator.
+func IsAdmin(db *sql.DB, username string) (bool, error) {
+	var isAdmin bool
+	err := db.QueryRow(`SELECT is_admin FROM auth_user WHERE username = $1`, username).Scan(&isAdmin)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return isAdmin, err
+}
@@ -17,12 +17,13 @@ func AuthMiddleware(c *gin.Context) {
 
 	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
-	_, err := auth.ParseJWT(tokenString)
+	claims, err := auth.ParseJWT(tokenString)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 		c.Abort()
 		return
 	}
 
+	c.Set("username", claims.Username)
 	c.Next()
 }
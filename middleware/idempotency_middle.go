@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"stock_exchange_Golang_project/config"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyHeader names the request header clients set to make a
+// mutating request safe to retry.
+const IdempotencyHeader = "Idempotency-Key"
+
+// reservationLease is how long a key stays reserved for a request that has
+// not stored its response. A reservation left behind by a request that
+// never finished, such as one cut short by a crash, expires after it so that
+// the key can be retried.
+const reservationLease = time.Minute
+
+// recordingWriter keeps a copy of the response body so that it can be
+// replayed.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes POST, PUT, PATCH and DELETE requests carrying
+// an Idempotency-Key header execute at most once per caller and key. The
+// first successful response is stored for the retention window and replayed
+// for every retry, marked with an Idempotent-Replayed header. Reusing a key
+// for a different request is rejected with 422, and a retry that arrives
+// while the first request is still running gets 409. Failed responses are
+// not stored, since the request made no change and may be retried. The
+// middleware must run after AuthMiddleware, which identifies the caller.
+func IdempotencyMiddleware(retention time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+		username := c.GetString("username")
+
+		db := config.ConnectDB()
+		defer db.Close()
+
+		_, err = db.Exec(`DELETE FROM idempotency_keys WHERE created_at < now() - make_interval(secs => $1)`, retention.Seconds())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+			c.Abort()
+			return
+		}
+
+		// Reservations whose lease ran out without a response are taken over.
+		query := `
+			INSERT INTO idempotency_keys (username, idempotency_key, request_hash)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (username, idempotency_key) DO UPDATE SET
+				request_hash = EXCLUDED.request_hash,
+				created_at = CURRENT_TIMESTAMP
+			WHERE idempotency_keys.status_code IS NULL
+				AND idempotency_keys.created_at < CURRENT_TIMESTAMP - make_interval(secs => $4)`
+		result, err := db.Exec(query, username, key, requestHash, reservationLease.Seconds())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+			c.Abort()
+			return
+		}
+		if reserved, _ := result.RowsAffected(); reserved == 0 {
+			replay(c, db, username, key, requestHash)
+			return
+		}
+
+		// Release the key unless a response is stored, including when the
+		// handler panics.
+		stored := false
+		defer func() {
+			if !stored {
+				db.Exec(`DELETE FROM idempotency_keys WHERE username = $1 AND idempotency_key = $2`, username, key)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		query = `
+			UPDATE idempotency_keys
+			SET status_code = $1, content_type = $2, response_body = $3
+			WHERE username = $4 AND idempotency_key = $5`
+		_, err = db.Exec(query, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes(), username, key)
+		stored = err == nil
+	}
+}
+
+// replay answers a request whose key has been seen before.
+func replay(c *gin.Context, db *sql.DB, username, key, requestHash string) {
+	var storedHash, contentType string
+	var statusCode sql.NullInt64
+	var body []byte
+	query := `
+		SELECT request_hash, status_code, content_type, response_body
+		FROM idempotency_keys
+		WHERE username = $1 AND idempotency_key = $2`
+	err := db.QueryRow(query, username, key).Scan(&storedHash, &statusCode, &contentType, &body)
+	switch {
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+	case storedHash != requestHash:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	case !statusCode.Valid:
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(int(statusCode.Int64), contentType, body)
+	}
+	c.Abort()
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    ticker VARCHAR(10) NOT NULL REFERENCES stocks(ticker),
+    side VARCHAR(4) NOT NULL CHECK (side IN ('BUY', 'SELL')),
+    price NUMERIC(10, 2) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    filled_quantity INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'NEW',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_book ON orders (ticker, side, status, price, created_at);
@@ -0,0 +1 @@
+ALTER TABLE transactions DROP COLUMN order_id;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE positions;
\ No newline at end of file
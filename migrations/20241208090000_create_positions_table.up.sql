@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS positions (
+    user_id INT NOT NULL REFERENCES users(id),
+    ticker VARCHAR(10) NOT NULL REFERENCES stocks(ticker),
+    quantity INT NOT NULL DEFAULT 0,
+    average_cost NUMERIC(14, 4) NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, ticker)
+);
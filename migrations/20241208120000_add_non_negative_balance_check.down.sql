@@ -0,0 +1 @@
+ALTER TABLE users DROP CONSTRAINT users_balance_non_negative;
\ No newline at end of file
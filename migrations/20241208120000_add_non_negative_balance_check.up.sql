@@ -0,0 +1 @@
+ALTER TABLE users ADD CONSTRAINT users_balance_non_negative CHECK (balance >= 0);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_orders_stops;
+UPDATE orders SET price = 0 WHERE price IS NULL;
+ALTER TABLE orders
+    DROP COLUMN order_type,
+    DROP COLUMN stop_price,
+    DROP COLUMN triggered,
+    ALTER COLUMN price SET NOT NULL;
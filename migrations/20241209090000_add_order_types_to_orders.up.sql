@@ -0,0 +1,8 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS order_type VARCHAR(20) NOT NULL DEFAULT 'LIMIT'
+        CHECK (order_type IN ('MARKET', 'LIMIT', 'STOP', 'STOP_LIMIT')),
+    ADD COLUMN IF NOT EXISTS stop_price NUMERIC(10, 2),
+    ADD COLUMN IF NOT EXISTS triggered BOOLEAN NOT NULL DEFAULT TRUE,
+    ALTER COLUMN price DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_stops ON orders (ticker, triggered) WHERE NOT triggered;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_expiry;
+ALTER TABLE orders
+    DROP COLUMN time_in_force,
+    DROP COLUMN expires_at;
@@ -0,0 +1,6 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS time_in_force VARCHAR(3) NOT NULL DEFAULT 'GTC'
+        CHECK (time_in_force IN ('DAY', 'GTC', 'IOC', 'FOK', 'GTD')),
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_orders_expiry ON orders (expires_at) WHERE expires_at IS NOT NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_orders_book;
+CREATE INDEX IF NOT EXISTS idx_orders_book ON orders (ticker, side, status, price, created_at);
+ALTER TABLE orders
+    DROP COLUMN priority,
+    DROP COLUMN updated_at;
+DROP SEQUENCE orders_priority_seq;
@@ -0,0 +1,11 @@
+CREATE SEQUENCE IF NOT EXISTS orders_priority_seq;
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS priority BIGINT NOT NULL DEFAULT nextval('orders_priority_seq'),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE orders SET priority = id, updated_at = created_at;
+SELECT setval('orders_priority_seq', COALESCE((SELECT MAX(id) FROM orders), 0) + 1, false);
+
+DROP INDEX IF EXISTS idx_orders_book;
+CREATE INDEX IF NOT EXISTS idx_orders_book ON orders (ticker, side, status, price, priority);
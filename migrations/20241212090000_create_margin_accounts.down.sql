@@ -0,0 +1,7 @@
+ALTER TABLE stocks
+    DROP COLUMN initial_margin,
+    DROP COLUMN maintenance_margin;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_balance_non_negative;
+ALTER TABLE users ADD CONSTRAINT users_balance_non_negative CHECK (balance >= 0);
+ALTER TABLE users DROP COLUMN account_type;
@@ -0,0 +1,13 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS account_type VARCHAR(10) NOT NULL DEFAULT 'CASH'
+        CHECK (account_type IN ('CASH', 'MARGIN'));
+
+-- Margin accounts may borrow cash, which shows as a negative balance.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_balance_non_negative;
+ALTER TABLE users ADD CONSTRAINT users_balance_non_negative CHECK (balance >= 0 OR account_type = 'MARGIN');
+
+ALTER TABLE stocks
+    ADD COLUMN IF NOT EXISTS initial_margin NUMERIC(5, 4) NOT NULL DEFAULT 0.5
+        CHECK (initial_margin > 0 AND initial_margin <= 1),
+    ADD COLUMN IF NOT EXISTS maintenance_margin NUMERIC(5, 4) NOT NULL DEFAULT 0.25
+        CHECK (maintenance_margin > 0 AND maintenance_margin <= 1);
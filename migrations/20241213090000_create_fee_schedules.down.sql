@@ -0,0 +1,5 @@
+ALTER TABLE transactions
+    DROP COLUMN fee,
+    DROP COLUMN liquidity;
+DROP TABLE exchange_accounts;
+DROP TABLE fee_schedules;
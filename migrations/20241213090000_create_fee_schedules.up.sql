@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    id SERIAL PRIMARY KEY,
+    liquidity VARCHAR(5) NOT NULL CHECK (liquidity IN ('MAKER', 'TAKER')),
+    min_monthly_volume INT NOT NULL DEFAULT 0 CHECK (min_monthly_volume >= 0),
+    per_share NUMERIC(10, 4) NOT NULL DEFAULT 0,
+    percentage NUMERIC(8, 6) NOT NULL DEFAULT 0,
+    flat NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    UNIQUE (liquidity, min_monthly_volume)
+);
+
+INSERT INTO fee_schedules (liquidity, min_monthly_volume, per_share, percentage, flat) VALUES
+    ('MAKER', 0, 0.0020, 0, 0),
+    ('TAKER', 0, 0.0030, 0, 0),
+    ('MAKER', 1000000, 0.0010, 0, 0),
+    ('TAKER', 1000000, 0.0020, 0, 0)
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS exchange_accounts (
+    name VARCHAR(50) PRIMARY KEY,
+    balance NUMERIC(14, 2) NOT NULL DEFAULT 0.00
+);
+
+INSERT INTO exchange_accounts (name) VALUES ('FEE_REVENUE') ON CONFLICT DO NOTHING;
+
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS fee NUMERIC(10, 2) NOT NULL DEFAULT 0.00,
+    ADD COLUMN IF NOT EXISTS liquidity VARCHAR(5) CHECK (liquidity IN ('MAKER', 'TAKER'));
@@ -0,0 +1,4 @@
+ALTER TABLE auth_user DROP COLUMN is_admin;
+DROP TABLE trading_halts;
+DROP TABLE market_holidays;
+DROP TABLE market_calendar;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS market_calendar (
+    id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'America/New_York',
+    pre_open_time TIME NOT NULL DEFAULT '04:00',
+    open_time TIME NOT NULL DEFAULT '09:30',
+    close_time TIME NOT NULL DEFAULT '16:00',
+    trading_days INT[] NOT NULL DEFAULT '{1,2,3,4,5}'
+);
+
+INSERT INTO market_calendar (id) VALUES (1) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS market_holidays (
+    date DATE PRIMARY KEY,
+    description VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS trading_halts (
+    id SERIAL PRIMARY KEY,
+    ticker VARCHAR(10) REFERENCES stocks(ticker),
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    halted_by VARCHAR(50) NOT NULL DEFAULT '',
+    halted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resume_at TIMESTAMP,
+    resumed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_trading_halts_active ON trading_halts (ticker) WHERE resumed_at IS NULL;
+
+ALTER TABLE auth_user ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,7 @@
+ALTER TABLE stocks
+    DROP COLUMN reference_price,
+    DROP COLUMN static_band,
+    DROP COLUMN dynamic_band,
+    DROP COLUMN circuit_breaker_threshold,
+    DROP COLUMN circuit_breaker_window_seconds,
+    DROP COLUMN circuit_breaker_halt_minutes;
@@ -0,0 +1,10 @@
+ALTER TABLE stocks
+    ADD COLUMN IF NOT EXISTS reference_price NUMERIC(10, 2),
+    ADD COLUMN IF NOT EXISTS static_band NUMERIC(6, 4) DEFAULT 0.20 CHECK (static_band > 0),
+    ADD COLUMN IF NOT EXISTS dynamic_band NUMERIC(6, 4) DEFAULT 0.05 CHECK (dynamic_band > 0),
+    ADD COLUMN IF NOT EXISTS circuit_breaker_threshold NUMERIC(6, 4) DEFAULT 0.10 CHECK (circuit_breaker_threshold > 0),
+    ADD COLUMN IF NOT EXISTS circuit_breaker_window_seconds INT NOT NULL DEFAULT 300 CHECK (circuit_breaker_window_seconds > 0),
+    ADD COLUMN IF NOT EXISTS circuit_breaker_halt_minutes INT NOT NULL DEFAULT 5 CHECK (circuit_breaker_halt_minutes > 0);
+
+UPDATE stocks SET reference_price = price WHERE reference_price IS NULL;
+ALTER TABLE stocks ALTER COLUMN reference_price SET NOT NULL;
@@ -0,0 +1,5 @@
+DELETE FROM exchange_accounts WHERE name = 'EXTERNAL_CASH';
+DROP TABLE postings;
+DROP FUNCTION check_journal_entry_balanced();
+DROP TABLE journal_entries;
+DROP TABLE ledger_accounts;
\ No newline at end of file
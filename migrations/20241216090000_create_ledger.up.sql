@@ -0,0 +1,75 @@
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    user_id INT UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id SERIAL PRIMARY KEY,
+    entry_type VARCHAR(20) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    reference VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS postings (
+    id SERIAL PRIMARY KEY,
+    entry_id INT NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    account_id INT NOT NULL REFERENCES ledger_accounts(id),
+    amount NUMERIC(14, 2) NOT NULL CHECK (amount <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_postings_account ON postings (account_id);
+CREATE INDEX IF NOT EXISTS idx_postings_entry ON postings (entry_id);
+
+-- Every journal entry must balance. The check is deferred to commit so that
+-- the postings of an entry can be inserted one at a time.
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT COALESCE(SUM(amount), 0) FROM postings WHERE entry_id = NEW.entry_id) <> 0 THEN
+        RAISE EXCEPTION 'journal entry % does not balance', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS postings_balanced ON postings;
+CREATE CONSTRAINT TRIGGER postings_balanced
+    AFTER INSERT OR UPDATE ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+INSERT INTO exchange_accounts (name) VALUES ('EXTERNAL_CASH') ON CONFLICT DO NOTHING;
+
+INSERT INTO ledger_accounts (name) SELECT name FROM exchange_accounts ON CONFLICT DO NOTHING;
+INSERT INTO ledger_accounts (name, user_id) SELECT 'USER:' || id, id FROM users ON CONFLICT DO NOTHING;
+
+-- Carry the balances held before the ledger existed over in one opening
+-- entry, funded from external cash.
+WITH entry AS (
+    INSERT INTO journal_entries (entry_type, description)
+    VALUES ('OPENING_BALANCE', 'Balances carried over to the ledger')
+    RETURNING id
+), balances AS (
+    SELECT a.id AS account_id, u.balance AS amount
+    FROM users u
+    INNER JOIN ledger_accounts a ON a.user_id = u.id
+    WHERE u.balance <> 0
+    UNION ALL
+    SELECT a.id, x.balance
+    FROM exchange_accounts x
+    INNER JOIN ledger_accounts a ON a.name = x.name
+    WHERE x.balance <> 0 AND x.name <> 'EXTERNAL_CASH'
+)
+INSERT INTO postings (entry_id, account_id, amount)
+SELECT entry.id, balances.account_id, balances.amount FROM entry, balances
+UNION ALL
+SELECT entry.id, (SELECT id FROM ledger_accounts WHERE name = 'EXTERNAL_CASH'), -SUM(balances.amount)
+FROM entry, balances
+GROUP BY entry.id
+HAVING SUM(balances.amount) <> 0;
+
+UPDATE exchange_accounts
+SET balance = (SELECT COALESCE(SUM(amount), 0) FROM postings p INNER JOIN ledger_accounts a ON a.id = p.account_id WHERE a.name = 'EXTERNAL_CASH')
+WHERE name = 'EXTERNAL_CASH';
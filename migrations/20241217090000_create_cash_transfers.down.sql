@@ -0,0 +1 @@
+DROP TABLE cash_transfers;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS cash_transfers (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('DEPOSIT', 'WITHDRAWAL')),
+    amount NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING'
+        CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'COMPLETED')),
+    rail VARCHAR(50),
+    rail_reference VARCHAR(100),
+    reviewed_by VARCHAR(50),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_transfers_user ON cash_transfers (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_cash_transfers_status ON cash_transfers (status);
+
+-- Withdrawals hold the requested cash here until they complete or are
+-- rejected.
+INSERT INTO exchange_accounts (name) VALUES ('PENDING_WITHDRAWALS') ON CONFLICT DO NOTHING;
+INSERT INTO ledger_accounts (name) VALUES ('PENDING_WITHDRAWALS') ON CONFLICT DO NOTHING;
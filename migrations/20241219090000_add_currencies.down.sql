@@ -0,0 +1,22 @@
+ALTER TABLE cash_transfers DROP COLUMN currency;
+
+ALTER TABLE transactions
+    DROP COLUMN currency,
+    DROP COLUMN fx_rate;
+
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT COALESCE(SUM(amount), 0) FROM postings WHERE entry_id = NEW.entry_id) <> 0 THEN
+        RAISE EXCEPTION 'journal entry % does not balance', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE ledger_accounts DROP CONSTRAINT ledger_accounts_user_id_currency_key;
+ALTER TABLE ledger_accounts ADD CONSTRAINT ledger_accounts_user_id_key UNIQUE (user_id);
+ALTER TABLE ledger_accounts DROP COLUMN currency;
+
+DROP TABLE cash_balances;
+ALTER TABLE stocks DROP COLUMN currency;
+DROP TABLE fx_rates;
\ No newline at end of file
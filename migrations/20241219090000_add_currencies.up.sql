@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS fx_rates (
+    currency CHAR(3) PRIMARY KEY CHECK (currency <> 'USD'),
+    rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- users.balance remains the base currency (USD) cash; cash held in any
+-- other currency is kept here. Only base currency cash may be borrowed.
+CREATE TABLE IF NOT EXISTS cash_balances (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    currency CHAR(3) NOT NULL CHECK (currency <> 'USD'),
+    balance NUMERIC(14, 2) NOT NULL DEFAULT 0.00 CHECK (balance >= 0),
+    PRIMARY KEY (user_id, currency)
+);
+
+ALTER TABLE ledger_accounts ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE ledger_accounts DROP CONSTRAINT IF EXISTS ledger_accounts_user_id_key;
+ALTER TABLE ledger_accounts ADD CONSTRAINT ledger_accounts_user_id_currency_key UNIQUE (user_id, currency);
+
+-- Journal entries now balance per currency.
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM postings p
+        INNER JOIN ledger_accounts a ON a.id = p.account_id
+        WHERE p.entry_id = NEW.entry_id
+        GROUP BY a.currency
+        HAVING SUM(p.amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % does not balance', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO exchange_accounts (name) VALUES ('FX_CONVERSION') ON CONFLICT DO NOTHING;
+INSERT INTO ledger_accounts (name) VALUES ('FX_CONVERSION') ON CONFLICT DO NOTHING;
+
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS fx_rate NUMERIC(18, 8) NOT NULL DEFAULT 1;
+
+ALTER TABLE cash_transfers ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
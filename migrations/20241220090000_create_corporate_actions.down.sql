@@ -0,0 +1,3 @@
+ALTER TABLE transactions DROP COLUMN split_factor;
+DROP TABLE dividend_entitlements;
+DROP TABLE corporate_actions;
\ No newline at end of file
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS corporate_actions (
+    id SERIAL PRIMARY KEY,
+    ticker VARCHAR(10) NOT NULL REFERENCES stocks(ticker),
+    action_type VARCHAR(10) NOT NULL CHECK (action_type IN ('SPLIT', 'DIVIDEND')),
+    split_from INT CHECK (split_from > 0),
+    split_to INT CHECK (split_to > 0),
+    dividend_per_share NUMERIC(14, 6) CHECK (dividend_per_share > 0),
+    ex_date DATE NOT NULL,
+    record_date DATE NOT NULL,
+    pay_date DATE NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'SCHEDULED'
+        CHECK (status IN ('SCHEDULED', 'RECORDED', 'COMPLETED', 'CANCELLED')),
+    announced_by VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP,
+    CHECK (
+        (action_type = 'SPLIT' AND split_from IS NOT NULL AND split_to IS NOT NULL AND split_from <> split_to AND dividend_per_share IS NULL)
+        OR (action_type = 'DIVIDEND' AND dividend_per_share IS NOT NULL AND split_from IS NULL AND split_to IS NULL)
+    ),
+    CHECK (ex_date <= record_date AND record_date <= pay_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_corporate_actions_ticker ON corporate_actions (ticker, ex_date);
+CREATE INDEX IF NOT EXISTS idx_corporate_actions_due ON corporate_actions (status, ex_date, pay_date);
+
+-- The dividend each holder is due, fixed on the ex-date. Short holders owe
+-- the dividend, so their amount is negative.
+CREATE TABLE IF NOT EXISTS dividend_entitlements (
+    action_id INT NOT NULL REFERENCES corporate_actions(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quantity INT NOT NULL,
+    amount NUMERIC(14, 2) NOT NULL,
+    paid_at TIMESTAMP,
+    PRIMARY KEY (action_id, user_id)
+);
+
+-- The product of every split ratio applied to the ticker since the trade.
+-- transaction_volume * split_factor is the trade's size in today's shares.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS split_factor NUMERIC NOT NULL DEFAULT 1;
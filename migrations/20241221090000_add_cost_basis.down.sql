@@ -0,0 +1,3 @@
+ALTER TABLE transactions DROP COLUMN realized_pnl;
+DROP TABLE tax_lots;
+ALTER TABLE users DROP COLUMN cost_basis_method;
\ No newline at end of file
@@ -0,0 +1,22 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS cost_basis_method VARCHAR(7) NOT NULL DEFAULT 'FIFO'
+    CHECK (cost_basis_method IN ('FIFO', 'LIFO', 'AVERAGE'));
+
+-- The open lots making up each position, consumed by closing trades in the
+-- order of the account's cost-basis method. Quantities carry the sign of the
+-- position.
+CREATE TABLE IF NOT EXISTS tax_lots (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ticker VARCHAR(10) NOT NULL REFERENCES stocks(ticker),
+    quantity INT NOT NULL,
+    cost NUMERIC(14, 4) NOT NULL,
+    opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_lots_user_ticker ON tax_lots (user_id, ticker);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS realized_pnl NUMERIC(14, 2) NOT NULL DEFAULT 0;
+
+-- Existing positions become a single lot at their average cost.
+INSERT INTO tax_lots (user_id, ticker, quantity, cost)
+SELECT user_id, ticker, quantity, average_cost FROM positions WHERE quantity <> 0;
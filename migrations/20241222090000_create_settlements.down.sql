@@ -0,0 +1,2 @@
+DROP TABLE settlements;
+ALTER TABLE market_calendar DROP COLUMN settlement_days;
\ No newline at end of file
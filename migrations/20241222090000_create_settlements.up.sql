@@ -0,0 +1,24 @@
+ALTER TABLE market_calendar ADD COLUMN IF NOT EXISTS settlement_days INT NOT NULL DEFAULT 1
+    CHECK (settlement_days BETWEEN 0 AND 5);
+
+-- One row per side of every trade: the shares and cash, commission
+-- included, the user receives (positive) or delivers (negative) when the
+-- trade settles. Trades made before the settlement cycle was introduced are
+-- treated as settled and have no row.
+CREATE TABLE IF NOT EXISTS settlements (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ticker VARCHAR(10) NOT NULL REFERENCES stocks(ticker),
+    quantity INT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    amount NUMERIC(14, 2) NOT NULL,
+    trade_date DATE NOT NULL,
+    settlement_date DATE NOT NULL,
+    status VARCHAR(7) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SETTLED')),
+    settled_at TIMESTAMP,
+    CHECK (trade_date <= settlement_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlements_user ON settlements (user_id, status);
+CREATE INDEX IF NOT EXISTS idx_settlements_due ON settlements (status, settlement_date);
@@ -0,0 +1 @@
+DROP TABLE share_adjustments;
\ No newline at end of file
@@ -0,0 +1,30 @@
+-- Changes to holdings that are not trades, in shares at the time they were
+-- made and restated by later splits through split_factor like transactions.
+-- Together with transactions they explain every position.
+CREATE TABLE IF NOT EXISTS share_adjustments (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ticker VARCHAR(10) NOT NULL REFERENCES stocks(ticker),
+    quantity NUMERIC(20, 8) NOT NULL,
+    reason VARCHAR(15) NOT NULL CHECK (reason IN ('OPENING', 'ISSUE', 'CASH_IN_LIEU', 'RECONCILIATION')),
+    split_factor NUMERIC NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_adjustments_user_ticker ON share_adjustments (user_id, ticker);
+
+-- Holdings that the trade history does not explain, such as shares issued
+-- before issuances were recorded, are carried over in one opening
+-- adjustment per position.
+INSERT INTO share_adjustments (user_id, ticker, quantity, reason)
+SELECT COALESCE(p.user_id, t.user_id), COALESCE(p.ticker, t.ticker), COALESCE(p.quantity, 0) - COALESCE(t.quantity, 0), 'OPENING'
+FROM positions p
+FULL JOIN (
+    SELECT user_id, ticker, SUM(CASE WHEN transaction_type = 'BUY' THEN transaction_volume ELSE -transaction_volume END * split_factor) AS quantity
+    FROM transactions
+    GROUP BY user_id, ticker
+) t ON t.user_id = p.user_id AND t.ticker = p.ticker
+WHERE COALESCE(p.quantity, 0) <> COALESCE(t.quantity, 0);
+
+INSERT INTO exchange_accounts (name) VALUES ('RECONCILIATION') ON CONFLICT DO NOTHING;
+INSERT INTO ledger_accounts (name) VALUES ('RECONCILIATION') ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+DROP TABLE price_history;
\ No newline at end of file
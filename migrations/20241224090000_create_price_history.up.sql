@@ -0,0 +1,24 @@
+-- Every price a stock has traded or been listed at. Like transactions,
+-- entries keep the price and volume they were recorded with and are
+-- restated in current shares through split_factor.
+CREATE TABLE IF NOT EXISTS price_history (
+    id BIGSERIAL PRIMARY KEY,
+    ticker VARCHAR(10) NOT NULL REFERENCES stocks(ticker) ON DELETE CASCADE,
+    price NUMERIC(14, 2) NOT NULL CHECK (price > 0),
+    volume INT NOT NULL DEFAULT 0 CHECK (volume >= 0),
+    split_factor NUMERIC NOT NULL DEFAULT 1,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_ticker_time ON price_history (ticker, recorded_at);
+
+-- Past trades are recovered from the buying side of each transaction; stocks
+-- that never traded start from their current price.
+INSERT INTO price_history (ticker, price, volume, split_factor, recorded_at)
+SELECT ticker, transaction_price / transaction_volume, transaction_volume, split_factor, timestamp
+FROM transactions
+WHERE transaction_type = 'BUY' AND transaction_volume > 0;
+
+INSERT INTO price_history (ticker, price)
+SELECT s.ticker, s.price FROM stocks s
+WHERE s.price > 0 AND NOT EXISTS (SELECT 1 FROM price_history h WHERE h.ticker = s.ticker);
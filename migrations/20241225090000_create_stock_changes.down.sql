@@ -0,0 +1,2 @@
+DROP TABLE stock_changes;
+ALTER TABLE stocks DROP COLUMN delisted_at;
\ No newline at end of file
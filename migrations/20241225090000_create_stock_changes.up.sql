@@ -0,0 +1,15 @@
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS delisted_at TIMESTAMP;
+
+-- Audit history of stock administration: who listed, changed or delisted a
+-- stock, when, and the old and new value of every setting that changed.
+CREATE TABLE IF NOT EXISTS stock_changes (
+    id SERIAL PRIMARY KEY,
+    ticker VARCHAR(10) NOT NULL REFERENCES stocks(ticker) ON DELETE CASCADE,
+    action VARCHAR(6) NOT NULL CHECK (action IN ('CREATE', 'UPDATE', 'DELIST')),
+    changes JSONB NOT NULL DEFAULT '{}',
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    changed_by VARCHAR(50) NOT NULL DEFAULT '',
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_changes_ticker ON stock_changes (ticker, changed_at);
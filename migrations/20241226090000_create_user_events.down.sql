@@ -0,0 +1,2 @@
+DROP TABLE user_events;
+ALTER TABLE users DROP COLUMN event_seq;
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS event_seq BIGINT NOT NULL DEFAULT 0;
+
+-- The private event feed of every user: their order updates, fills and
+-- cash balance changes. seq numbers a user's events in commit order; it is
+-- taken from users.event_seq, whose row lock serializes the writers.
+CREATE TABLE IF NOT EXISTS user_events (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL,
+    event_type VARCHAR(7) NOT NULL CHECK (event_type IN ('ORDER', 'FILL', 'BALANCE')),
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, seq)
+);
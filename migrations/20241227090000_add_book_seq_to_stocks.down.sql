@@ -0,0 +1 @@
+ALTER TABLE stocks DROP COLUMN book_seq;
\ No newline at end of file
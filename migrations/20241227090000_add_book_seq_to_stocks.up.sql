@@ -0,0 +1,3 @@
+-- Numbers the published versions of each ticker's order book in commit
+-- order; the stock row lock serializes the writers.
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS book_seq BIGINT NOT NULL DEFAULT 0;
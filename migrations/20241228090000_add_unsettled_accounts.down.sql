@@ -0,0 +1,38 @@
+ALTER TABLE positions DROP COLUMN unsettled_quantity;
+
+-- Return unsettled proceeds to cash by moving the postings of every
+-- unsettled account to the matching cash account.
+INSERT INTO ledger_accounts (name, user_id, currency)
+SELECT 'USER:' || user_id || ':' || currency, user_id, currency
+FROM ledger_accounts
+WHERE unsettled AND currency <> 'USD'
+ON CONFLICT DO NOTHING;
+
+UPDATE users u SET balance = u.balance + s.amount
+FROM (
+    SELECT a.user_id, SUM(p.amount) AS amount
+    FROM postings p
+    INNER JOIN ledger_accounts a ON a.id = p.account_id
+    WHERE a.unsettled AND a.currency = 'USD'
+    GROUP BY a.user_id
+) s
+WHERE s.user_id = u.id;
+
+INSERT INTO cash_balances (user_id, currency, balance)
+SELECT a.user_id, a.currency, SUM(p.amount)
+FROM postings p
+INNER JOIN ledger_accounts a ON a.id = p.account_id
+WHERE a.unsettled AND a.currency <> 'USD'
+GROUP BY a.user_id, a.currency
+ON CONFLICT (user_id, currency) DO UPDATE SET balance = cash_balances.balance + EXCLUDED.balance;
+
+UPDATE postings p SET account_id = c.id
+FROM ledger_accounts a, ledger_accounts c
+WHERE a.id = p.account_id AND a.unsettled
+    AND c.user_id = a.user_id AND c.currency = a.currency AND NOT c.unsettled;
+
+DELETE FROM ledger_accounts WHERE unsettled;
+
+ALTER TABLE ledger_accounts DROP CONSTRAINT ledger_accounts_user_id_currency_key;
+ALTER TABLE ledger_accounts ADD CONSTRAINT ledger_accounts_user_id_currency_key UNIQUE (user_id, currency);
+ALTER TABLE ledger_accounts DROP COLUMN unsettled;
@@ -0,0 +1,59 @@
+-- Users get an unsettled account in every currency they receive trade
+-- proceeds in. The cash waits there until the trade settles and then moves
+-- to their cash account.
+ALTER TABLE ledger_accounts ADD COLUMN IF NOT EXISTS unsettled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE ledger_accounts DROP CONSTRAINT IF EXISTS ledger_accounts_user_id_currency_key;
+ALTER TABLE ledger_accounts ADD CONSTRAINT ledger_accounts_user_id_currency_key UNIQUE (user_id, currency, unsettled);
+
+INSERT INTO ledger_accounts (name, user_id, unsettled)
+SELECT 'USER:' || id || ':UNSETTLED', id, TRUE FROM users
+ON CONFLICT DO NOTHING;
+
+INSERT INTO ledger_accounts (name, user_id, currency, unsettled)
+SELECT DISTINCT 'USER:' || user_id || ':UNSETTLED:' || currency, user_id, currency, TRUE
+FROM settlements
+WHERE status = 'PENDING' AND amount > 0 AND currency <> 'USD'
+ON CONFLICT DO NOTHING;
+
+-- Proceeds of pending trades were booked to cash when the trades executed;
+-- move them to the unsettled accounts.
+CREATE TEMPORARY TABLE pending_proceeds AS
+SELECT user_id, currency, SUM(amount) AS amount
+FROM settlements
+WHERE status = 'PENDING' AND amount > 0
+GROUP BY user_id, currency;
+
+WITH entry AS (
+    INSERT INTO journal_entries (entry_type, description)
+    SELECT 'SETTLEMENT', 'Proceeds of unsettled trades moved to unsettled accounts'
+    WHERE EXISTS (SELECT 1 FROM pending_proceeds)
+    RETURNING id
+)
+INSERT INTO postings (entry_id, account_id, amount)
+SELECT entry.id, a.id, CASE WHEN a.unsettled THEN p.amount ELSE -p.amount END
+FROM entry, pending_proceeds p
+INNER JOIN ledger_accounts a ON a.user_id = p.user_id AND a.currency = p.currency;
+
+UPDATE users u SET balance = u.balance - p.amount
+FROM pending_proceeds p
+WHERE p.user_id = u.id AND p.currency = 'USD';
+
+UPDATE cash_balances c SET balance = c.balance - p.amount
+FROM pending_proceeds p
+WHERE p.user_id = c.user_id AND p.currency = c.currency;
+
+DROP TABLE pending_proceeds;
+
+-- Shares bought (positive) or sold (negative) in trades that have not
+-- settled yet, in today's shares. They are part of quantity.
+ALTER TABLE positions ADD COLUMN IF NOT EXISTS unsettled_quantity INT NOT NULL DEFAULT 0;
+
+UPDATE positions p SET unsettled_quantity = s.quantity
+FROM (
+    SELECT s.user_id, s.ticker, SUM(TRUNC(s.quantity * t.split_factor))::int AS quantity
+    FROM settlements s
+    INNER JOIN transactions t ON t.id = s.transaction_id
+    WHERE s.status = 'PENDING'
+    GROUP BY s.user_id, s.ticker
+) s
+WHERE s.user_id = p.user_id AND s.ticker = p.ticker;
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN auth_user_id;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- The login that owns each trading account. Accounts are only reachable
+-- through the login linked to them, never by a login that merely shares
+-- their name; accounts admins open for others have none.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_user_id INT UNIQUE REFERENCES auth_user(id) ON DELETE SET NULL;
+
+-- Existing accounts belong to the one login with the same name, if any.
+UPDATE users u SET auth_user_id = a.id
+FROM auth_user a
+WHERE LOWER(a.username) = LOWER(u.username)
+    AND (SELECT COUNT(*) FROM auth_user o WHERE LOWER(o.username) = LOWER(u.username)) = 1
+    AND (SELECT COUNT(*) FROM users o WHERE LOWER(o.username) = LOWER(u.username)) = 1;
@@ -0,0 +1,2 @@
+ALTER TABLE transactions ALTER COLUMN transaction_price TYPE NUMERIC(10, 2);
+ALTER TABLE users ALTER COLUMN balance TYPE DECIMAL(10, 2);
@@ -0,0 +1,4 @@
+-- users.balance and transactions.transaction_price hold the same amounts
+-- as the ledger, so they take the ledger's precision.
+ALTER TABLE users ALTER COLUMN balance TYPE NUMERIC(14, 2);
+ALTER TABLE transactions ALTER COLUMN transaction_price TYPE NUMERIC(14, 2);
@@ -0,0 +1,12 @@
+package models
+
+import "stock_exchange_Golang_project/utils/decimal"
+
+type FeeSchedule struct {
+	ID               int             `json:"id"`
+	Liquidity        string          `json:"liquidity"`
+	MinMonthlyVolume int             `json:"min_monthly_volume"`
+	PerShare         decimal.Decimal `json:"per_share"`
+	Percentage       decimal.Decimal `json:"percentage"`
+	Flat             decimal.Decimal `json:"flat"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+type Order struct {
+	ID             int             `json:"id"`
+	UserID         int             `json:"user_id"`
+	Ticker         string          `json:"ticker"`
+	Side           string          `json:"side"`
+	OrderType      string          `json:"order_type"`
+	TimeInForce    string          `json:"time_in_force"`
+	Price          decimal.Decimal `json:"price"`
+	StopPrice      decimal.Decimal `json:"stop_price"`
+	Triggered      bool            `json:"triggered"`
+	Quantity       int             `json:"quantity"`
+	FilledQuantity int             `json:"filled_quantity"`
+	Status         string          `json:"status"`
+	ExpiresAt      *time.Time      `json:"expires_at,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	UpdatedAt      string          `json:"updated_at"`
+}
+
+// Remaining returns the quantity of the order still open on the book.
+func (order *Order) Remaining() int {
+	return order.Quantity - order.FilledQuantity
+}
@@ -0,0 +1,11 @@
+package models
+
+import "stock_exchange_Golang_project/utils/decimal"
+
+type Position struct {
+	UserID          int             `json:"user_id"`
+	Ticker          string          `json:"ticker"`
+	Quantity        int             `json:"quantity"`
+	SettledQuantity int             `json:"settled_quantity"`
+	AverageCost     decimal.Decimal `json:"average_cost"`
+}
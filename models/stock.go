@@ -1,7 +1,9 @@
 package models
 
+import "stock_exchange_Golang_project/utils/decimal"
+
 type Stock struct {
 	ID    int     `json:"id"`
 	Ticker string `json:"ticker"`
-	Price  float64 `json:"price"`
+	Price  decimal.Decimal `json:"price"`
 }
@@ -1,11 +1,13 @@
 package models
 
+import "stock_exchange_Golang_project/utils/decimal"
+
 type Transaction struct {
 	ID               int     `json:"id"`
 	UserID           int     `json:"user_id"`
 	Ticker           string  `json:"ticker"`
 	TransactionType  string  `json:"transaction_type"`
 	TransactionVolume int    `json:"transaction_volume"`
-	TransactionPrice  float64 `json:"transaction_price"`
+	TransactionPrice  decimal.Decimal `json:"transaction_price"`
 	Timestamp         string  `json:"timestamp"`
 }
@@ -1,7 +1,9 @@
 package models
 
+import "stock_exchange_Golang_project/utils/decimal"
+
 type User struct {
 	ID       int     `json:"id"`
 	Username string  `json:"username"`
-	Balance  float64 `json:"balance"`
+	Balance  decimal.Decimal `json:"balance"`
 }
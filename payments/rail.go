@@ -0,0 +1,99 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrNoRail     = errors.New("no payment rail configured")
+	ErrRailFailed = errors.New("payment rail failed")
+)
+
+// Rail moves money between the exchange and users' external accounts. Both
+// methods are called once a transfer has been approved and return the rail's
+// own reference for the payment. A rail may be asked to process the same
+// transfer again when completing it failed, so implementations must be
+// idempotent on the transfer ID.
+type Rail interface {
+	// Name identifies the rail on the transfers it processed.
+	Name() string
+	// Collect pulls the amount of a deposit into the exchange.
+	Collect(transfer *Transfer) (string, error)
+	// Pay sends the amount of a withdrawal to the user.
+	Pay(transfer *Transfer) (string, error)
+}
+
+var (
+	railMu sync.RWMutex
+	rail   Rail
+)
+
+// UseRail sets the rail approved transfers are processed on.
+func UseRail(r Rail) {
+	railMu.Lock()
+	defer railMu.Unlock()
+	rail = r
+}
+
+func currentRail() (Rail, error) {
+	railMu.RLock()
+	defer railMu.RUnlock()
+	if rail == nil {
+		return nil, ErrNoRail
+	}
+	return rail, nil
+}
+
+// FakeRail is an in-process rail that settles every payment immediately. It
+// remembers what it processed so that tests can inspect it, and can be told
+// to fail.
+type FakeRail struct {
+	mu         sync.Mutex
+	references map[int]string
+	failure    error
+	Collected  []Transfer
+	Paid       []Transfer
+}
+
+func NewFakeRail() *FakeRail {
+	return &FakeRail{references: map[int]string{}}
+}
+
+func (fake *FakeRail) Name() string {
+	return "FAKE"
+}
+
+// FailWith makes every following payment fail with err until it is called
+// again with nil.
+func (fake *FakeRail) FailWith(err error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.failure = err
+}
+
+func (fake *FakeRail) Collect(transfer *Transfer) (string, error) {
+	return fake.process(transfer, &fake.Collected)
+}
+
+func (fake *FakeRail) Pay(transfer *Transfer) (string, error) {
+	return fake.process(transfer, &fake.Paid)
+}
+
+func (fake *FakeRail) process(transfer *Transfer, processed *[]Transfer) (string, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if fake.failure != nil {
+		return "", fake.failure
+	}
+	if reference, ok := fake.references[transfer.ID]; ok {
+		return reference, nil
+	}
+
+	reference := fmt.Sprintf("FAKE-%d", len(fake.references)+1)
+	fake.references[transfer.ID] = reference
+	*processed = append(*processed, *transfer)
+	return reference, nil
+}
@@ -0,0 +1,332 @@
+// Package payments handles cash deposits and withdrawals. A user requests a
+// transfer, an admin approves or rejects it, and approved transfers are sent
+// to the configured payment Rail and booked to the ledger once it accepts
+// them. Withdrawals hold the requested cash from the moment they are
+// requested so that it cannot be spent twice. Transfers may be made in any
+// currency with a configured exchange rate, and move the user's cash in
+// that currency.
+package payments
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	Deposit    = "DEPOSIT"
+	Withdrawal = "WITHDRAWAL"
+)
+
+const (
+	StatusPending   = "PENDING"
+	StatusApproved  = "APPROVED"
+	StatusRejected  = "REJECTED"
+	StatusCompleted = "COMPLETED"
+)
+
+var (
+	ErrTransferNotFound = errors.New("transfer not found")
+	ErrTransferClosed   = errors.New("transfer can no longer change state")
+	ErrInvalidAmount    = errors.New("amount must be positive and in whole cents")
+	ErrInsufficientCash = errors.New("insufficient withdrawable cash")
+)
+
+// Transfer is a request to move cash into or out of a user's account.
+type Transfer struct {
+	ID            int             `json:"id"`
+	UserID        int             `json:"user_id"`
+	Direction     string          `json:"direction"`
+	Currency      string          `json:"currency"`
+	Amount        decimal.Decimal `json:"amount"`
+	Status        string          `json:"status"`
+	Rail          string          `json:"rail,omitempty"`
+	RailReference string          `json:"rail_reference,omitempty"`
+	ReviewedBy    string          `json:"reviewed_by,omitempty"`
+	Reason        string          `json:"reason,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+}
+
+// Filter narrows List. Empty fields match every transfer.
+type Filter struct {
+	UserID int
+	Status string
+}
+
+const transferColumns = `id, user_id, direction, currency, amount, status, COALESCE(rail, ''), COALESCE(rail_reference, ''),
+	COALESCE(reviewed_by, ''), reason, created_at, updated_at, completed_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransfer(row scanner) (*Transfer, error) {
+	var transfer Transfer
+	err := row.Scan(&transfer.ID, &transfer.UserID, &transfer.Direction, &transfer.Currency, &transfer.Amount, &transfer.Status, &transfer.Rail,
+		&transfer.RailReference, &transfer.ReviewedBy, &transfer.Reason, &transfer.CreatedAt, &transfer.UpdatedAt, &transfer.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// Request records a pending deposit or withdrawal of currency for a user.
+// Withdrawals are limited to the cash the user can take out without
+// borrowing: the cash balance of a cash account, or the lesser of cash and
+// excess equity for a margin account. Their amount is moved to the pending
+// withdrawals account straight away.
+func Request(tx *sql.Tx, userID int, direction, currency string, amount decimal.Decimal) (*Transfer, error) {
+	if direction != Deposit && direction != Withdrawal {
+		return nil, fmt.Errorf("invalid direction %q", direction)
+	}
+	if !amount.IsPositive() || !amount.Equal(amount.Round(decimal.Cents, decimal.Down)) {
+		return nil, ErrInvalidAmount
+	}
+	if !fx.ValidCurrency(currency) {
+		return nil, fx.ErrInvalidCurrency
+	}
+	// Cash that cannot be valued would leave the account unpriceable.
+	if _, err := fx.Load(tx, currency); err != nil {
+		return nil, err
+	}
+
+	var id int
+	err := tx.QueryRow(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, engine.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if direction == Withdrawal {
+		withdrawable, err := Withdrawable(tx, userID, currency)
+		if err != nil {
+			return nil, err
+		}
+		if withdrawable.LessThan(amount) {
+			return nil, ErrInsufficientCash
+		}
+	}
+
+	query := `
+		INSERT INTO cash_transfers (user_id, direction, currency, amount)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + transferColumns
+	transfer, err := scanTransfer(tx.QueryRow(query, userID, direction, currency, amount))
+	if err != nil {
+		return nil, err
+	}
+
+	if direction == Withdrawal {
+		err = transfer.post(tx, fmt.Sprintf("Withdrawal #%d requested", transfer.ID), ledger.UserAccount(userID), ledger.PendingWithdrawalsAccount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return transfer, nil
+}
+
+// Withdrawable returns the cash in currency a user could withdraw right now.
+// Cash received from trades that have not settled cannot be withdrawn.
+func Withdrawable(q engine.Querier, userID int, currency string) (decimal.Decimal, error) {
+	account, err := engine.LoadAccount(q, userID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	withdrawable, err := engine.SettledCash(q, userID, currency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	if account.AccountType == engine.MarginAccount {
+		rate, err := fx.Load(q, currency)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		excess := decimal.Max(account.ExcessEquity, decimal.Zero)
+		withdrawable = decimal.Min(withdrawable, fx.FromBase(excess, rate, decimal.Down))
+	}
+	return decimal.Max(withdrawable, decimal.Zero), nil
+}
+
+// Find returns the transfer with the given ID.
+func Find(q engine.Querier, id int) (*Transfer, error) {
+	transfer, err := scanTransfer(q.QueryRow(`SELECT `+transferColumns+` FROM cash_transfers WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, err
+}
+
+// List returns the transfers matching filter, newest first.
+func List(q engine.Querier, filter Filter) ([]Transfer, error) {
+	query := `
+		SELECT ` + transferColumns + `
+		FROM cash_transfers
+		WHERE ($1 = 0 OR user_id = $1) AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id DESC`
+	rows, err := q.Query(query, filter.UserID, filter.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []Transfer{}
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+// Approve approves a pending transfer, sends it to the payment rail and,
+// once the rail accepts it, books it to the ledger and marks it completed.
+// When the rail fails the transfer stays approved and Approve can be called
+// again to retry it.
+func Approve(db *sql.DB, id int, reviewer string) (*Transfer, error) {
+	var transfer *Transfer
+	err := engine.RunInTx(db, func(tx *sql.Tx) error {
+		var err error
+		transfer, err = lock(tx, id, StatusPending, StatusApproved)
+		if err != nil {
+			return err
+		}
+		return transfer.update(tx, StatusApproved, reviewer, transfer.Reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := currentRail()
+	if err != nil {
+		return transfer, err
+	}
+	pay := r.Collect
+	if transfer.Direction == Withdrawal {
+		pay = r.Pay
+	}
+	railReference, err := pay(transfer)
+	if err != nil {
+		return transfer, fmt.Errorf("%w: %s: %v", ErrRailFailed, r.Name(), err)
+	}
+
+	err = engine.RunInTx(db, func(tx *sql.Tx) error {
+		var err error
+		transfer, err = lock(tx, id, StatusApproved)
+		if err != nil {
+			return err
+		}
+
+		if transfer.Direction == Deposit {
+			err = transfer.post(tx, fmt.Sprintf("Deposit #%d", transfer.ID), ledger.ExternalCashAccount, ledger.UserAccount(transfer.UserID))
+		} else {
+			err = transfer.post(tx, fmt.Sprintf("Withdrawal #%d paid", transfer.ID), ledger.PendingWithdrawalsAccount, ledger.ExternalCashAccount)
+		}
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE cash_transfers
+			SET status = $1, rail = $2, rail_reference = $3, updated_at = now(), completed_at = now()
+			WHERE id = $4
+			RETURNING ` + transferColumns
+		transfer, err = scanTransfer(tx.QueryRow(query, StatusCompleted, r.Name(), railReference, id))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// Reject turns down a transfer that has not completed. The cash held by a
+// rejected withdrawal is returned to the user.
+func Reject(tx *sql.Tx, id int, reviewer, reason string) (*Transfer, error) {
+	transfer, err := lock(tx, id, StatusPending, StatusApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	if transfer.Direction == Withdrawal {
+		err = transfer.post(tx, fmt.Sprintf("Withdrawal #%d rejected", transfer.ID), ledger.PendingWithdrawalsAccount, ledger.UserAccount(transfer.UserID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := transfer.update(tx, StatusRejected, reviewer, reason); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// lock locks the transfer row and checks that it is in one of the given
+// states.
+func lock(tx *sql.Tx, id int, states ...string) (*Transfer, error) {
+	transfer, err := scanTransfer(tx.QueryRow(`SELECT `+transferColumns+` FROM cash_transfers WHERE id = $1 FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTransferNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, state := range states {
+		if transfer.Status == state {
+			return transfer, nil
+		}
+	}
+	return nil, ErrTransferClosed
+}
+
+func (transfer *Transfer) update(tx *sql.Tx, status, reviewer, reason string) error {
+	query := `
+		UPDATE cash_transfers
+		SET status = $1, reviewed_by = $2, reason = $3, updated_at = now()
+		WHERE id = $4
+		RETURNING updated_at`
+	err := tx.QueryRow(query, status, reviewer, reason, transfer.ID).Scan(&transfer.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	transfer.Status, transfer.ReviewedBy, transfer.Reason = status, reviewer, reason
+	return nil
+}
+
+// post moves the amount of transfer between the accounts from and to in the
+// transfer's currency.
+func (transfer *Transfer) post(tx *sql.Tx, description, from, to string) error {
+	from, err := ledger.CurrencyAccount(tx, from, transfer.Currency)
+	if err != nil {
+		return err
+	}
+	to, err = ledger.CurrencyAccount(tx, to, transfer.Currency)
+	if err != nil {
+		return err
+	}
+
+	entryType := ledger.EntryDeposit
+	if transfer.Direction == Withdrawal {
+		entryType = ledger.EntryWithdrawal
+	}
+	return ledger.Transfer(tx, entryType, description, reference(transfer), from, to, transfer.Amount)
+}
+
+// reference ties ledger entries to the transfer that caused them.
+func reference(transfer *Transfer) string {
+	return fmt.Sprintf("cash_transfers:%d", transfer.ID)
+}
@@ -0,0 +1,297 @@
+// Package reconcile checks the balances and holdings the exchange keeps
+// against the records they are derived from, and reports every difference
+// as a break:
+//
+//   - CASH_BALANCE: a user's cash in users.balance or cash_balances differs
+//     from the sum of the postings to their ledger account.
+//   - TRADE_CASH: the cash the ledger moved for a user's trades and
+//     commissions, settled or not, differs from what their transactions
+//     add up to.
+//   - POSITION: a position differs from the user's trades and share
+//     adjustments, restated by later splits.
+//   - TAX_LOTS: a position's open tax lots do not add up to it.
+//
+// Breaks can optionally be corrected. The ledger and the transaction
+// history are treated as the books of record: cached balances, positions
+// and lots are rewritten to agree with them, and trade cash is corrected by
+// posting an adjustment against the exchange's reconciliation account.
+package reconcile
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/fx"
+	"stock_exchange_Golang_project/ledger"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	BreakCashBalance = "CASH_BALANCE"
+	BreakTradeCash   = "TRADE_CASH"
+	BreakPosition    = "POSITION"
+	BreakTaxLots     = "TAX_LOTS"
+)
+
+// quantityPlaces is the precision positions are reconciled to. Fractional
+// shares cashed out in splits are recorded as repeating decimals, so sums
+// of them can be off in the last places.
+const quantityPlaces = 4
+
+// Break is a difference between a stored value and the value expected from
+// the records it is derived from. Cash breaks carry a currency and are in
+// that currency; position and lot breaks carry a ticker and are in shares.
+type Break struct {
+	Kind       string          `json:"kind"`
+	UserID     int             `json:"user_id"`
+	Username   string          `json:"username"`
+	Ticker     string          `json:"ticker,omitempty"`
+	Currency   string          `json:"currency,omitempty"`
+	Expected   decimal.Decimal `json:"expected"`
+	Actual     decimal.Decimal `json:"actual"`
+	Difference decimal.Decimal `json:"difference"`
+	Corrected  bool            `json:"corrected"`
+}
+
+// Report is the outcome of one reconciliation run.
+type Report struct {
+	RunAt     time.Time `json:"run_at"`
+	Breaks    []Break   `json:"breaks"`
+	Corrected int       `json:"corrected"`
+}
+
+// Run reconciles every user in one transaction, correcting the breaks it
+// finds when correct is set. Positions are corrected before their lots are
+// checked, and cached balances before trade cash, so that each check sees
+// the corrections of the one it depends on. Positions are only corrected to
+// whole shares.
+func Run(db *sql.DB, correct bool) (*Report, error) {
+	var report *Report
+	err := engine.RunInTx(db, func(tx *sql.Tx) error {
+		report = &Report{RunAt: time.Now(), Breaks: []Break{}}
+		for _, check := range []func(*sql.Tx, bool) ([]Break, error){
+			cashBalances,
+			tradeCash,
+			positions,
+			taxLots,
+		} {
+			breaks, err := check(tx, correct)
+			if err != nil {
+				return err
+			}
+			for _, b := range breaks {
+				if b.Corrected {
+					report.Corrected++
+				}
+			}
+			report.Breaks = append(report.Breaks, breaks...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reconcile: %w", err)
+	}
+	return report, nil
+}
+
+// collect reads the breaks returned by query, which must select the user
+// ID, username, ticker or currency, expected value and actual value.
+func collect(tx *sql.Tx, kind string, query string, args ...interface{}) ([]Break, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	breaks := []Break{}
+	for rows.Next() {
+		b := Break{Kind: kind}
+		var key string
+		if err := rows.Scan(&b.UserID, &b.Username, &key, &b.Expected, &b.Actual); err != nil {
+			return nil, err
+		}
+		if kind == BreakCashBalance || kind == BreakTradeCash {
+			b.Currency = key
+		} else {
+			b.Ticker = key
+		}
+		b.Difference = b.Expected.Sub(b.Actual)
+		breaks = append(breaks, b)
+	}
+	return breaks, rows.Err()
+}
+
+// cashBalances compares the cash cached for every user with the postings
+// to their ledger accounts, and rewrites the cache to match the ledger.
+func cashBalances(tx *sql.Tx, correct bool) ([]Break, error) {
+	query := `
+		SELECT a.user_id, u.username, a.currency, COALESCE(SUM(p.amount), 0),
+			CASE WHEN a.currency = $1 THEN u.balance ELSE COALESCE(c.balance, 0) END
+		FROM ledger_accounts a
+		INNER JOIN users u ON u.id = a.user_id
+		LEFT JOIN postings p ON p.account_id = a.id
+		LEFT JOIN cash_balances c ON c.user_id = a.user_id AND c.currency = a.currency
+		WHERE NOT a.unsettled
+		GROUP BY a.user_id, u.username, a.currency, u.balance, c.balance
+		HAVING COALESCE(SUM(p.amount), 0) <> CASE WHEN a.currency = $1 THEN u.balance ELSE COALESCE(c.balance, 0) END
+		ORDER BY a.user_id, a.currency`
+	breaks, err := collect(tx, BreakCashBalance, query, fx.Base)
+	if err != nil || !correct {
+		return breaks, err
+	}
+
+	for i, b := range breaks {
+		if b.Currency == fx.Base {
+			_, err = tx.Exec(`UPDATE users SET balance = $1 WHERE id = $2`, b.Expected, b.UserID)
+		} else {
+			query := `
+				INSERT INTO cash_balances (user_id, currency, balance)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (user_id, currency) DO UPDATE SET balance = EXCLUDED.balance`
+			_, err = tx.Exec(query, b.UserID, b.Currency, b.Expected)
+		}
+		if err != nil {
+			return nil, err
+		}
+		breaks[i].Corrected = true
+	}
+	return breaks, nil
+}
+
+// tradeCash compares, per user and currency, the cash posted to the ledger
+// for trades and commissions with the proceeds, costs and fees recorded on
+// the user's transactions. Transactions made before the ledger was opened
+// were carried over in its opening balances and are left out. Breaks are
+// corrected by posting the difference against the reconciliation account.
+func tradeCash(tx *sql.Tx, correct bool) ([]Break, error) {
+	query := `
+		WITH opened AS (
+			SELECT COALESCE(MIN(created_at), '-infinity') AS at FROM journal_entries WHERE entry_type = $1
+		), expected AS (
+			SELECT t.user_id, t.currency,
+				SUM(CASE WHEN t.transaction_type = 'SELL' THEN t.transaction_price ELSE -t.transaction_price END - t.fee) AS amount
+			FROM transactions t, opened
+			WHERE t.timestamp >= opened.at
+			GROUP BY t.user_id, t.currency
+		), booked AS (
+			SELECT a.user_id, a.currency, SUM(p.amount) AS amount
+			FROM postings p
+			INNER JOIN journal_entries e ON e.id = p.entry_id
+			INNER JOIN ledger_accounts a ON a.id = p.account_id
+			WHERE a.user_id IS NOT NULL AND e.entry_type IN ($2, $3, $4)
+			GROUP BY a.user_id, a.currency
+		)
+		SELECT u.id, u.username, COALESCE(e.currency, b.currency), COALESCE(e.amount, 0), COALESCE(b.amount, 0)
+		FROM expected e
+		FULL JOIN booked b ON b.user_id = e.user_id AND b.currency = e.currency
+		INNER JOIN users u ON u.id = COALESCE(e.user_id, b.user_id)
+		WHERE COALESCE(e.amount, 0) <> COALESCE(b.amount, 0)
+		ORDER BY u.id, 3`
+	breaks, err := collect(tx, BreakTradeCash, query, ledger.EntryOpeningBalance, ledger.EntryTrade, ledger.EntryFee,
+		ledger.EntryTradeAdjustment)
+	if err != nil || !correct {
+		return breaks, err
+	}
+
+	for i, b := range breaks {
+		account, err := ledger.CurrencyAccount(tx, ledger.UserAccount(b.UserID), b.Currency)
+		if err != nil {
+			return nil, err
+		}
+		reconciliation, err := ledger.CurrencyAccount(tx, ledger.ReconciliationAccount, b.Currency)
+		if err != nil {
+			return nil, err
+		}
+		err = ledger.Transfer(tx, ledger.EntryTradeAdjustment, "Reconciliation of trade cash", "", reconciliation,
+			account, b.Difference)
+		if err != nil {
+			return nil, err
+		}
+		breaks[i].Corrected = true
+	}
+	return breaks, nil
+}
+
+// positions compares every position with the user's trades and share
+// adjustments in the ticker, restated in current shares. Breaks expecting a
+// whole number of shares are corrected by rewriting the position.
+func positions(tx *sql.Tx, correct bool) ([]Break, error) {
+	query := `
+		WITH changes AS (
+			SELECT user_id, ticker, CASE WHEN transaction_type = 'BUY' THEN transaction_volume ELSE -transaction_volume END * split_factor AS quantity
+			FROM transactions
+			UNION ALL
+			SELECT user_id, ticker, quantity * split_factor
+			FROM share_adjustments
+		), expected AS (
+			SELECT user_id, ticker, ROUND(SUM(quantity), $1) AS quantity
+			FROM changes
+			GROUP BY user_id, ticker
+		)
+		SELECT u.id, u.username, COALESCE(e.ticker, p.ticker), COALESCE(e.quantity, 0), COALESCE(p.quantity, 0)
+		FROM expected e
+		FULL JOIN positions p ON p.user_id = e.user_id AND p.ticker = e.ticker
+		INNER JOIN users u ON u.id = COALESCE(e.user_id, p.user_id)
+		WHERE COALESCE(e.quantity, 0) <> COALESCE(p.quantity, 0)
+		ORDER BY u.id, 3`
+	breaks, err := collect(tx, BreakPosition, query, quantityPlaces)
+	if err != nil || !correct {
+		return breaks, err
+	}
+
+	for i, b := range breaks {
+		if !b.Expected.Equal(b.Expected.Round(0, decimal.Down)) {
+			continue
+		}
+		query := `
+			INSERT INTO positions (user_id, ticker, quantity, average_cost)
+			VALUES ($1, $2, $3, 0)
+			ON CONFLICT (user_id, ticker) DO UPDATE SET
+				quantity = EXCLUDED.quantity,
+				average_cost = CASE WHEN EXCLUDED.quantity = 0 THEN 0 ELSE positions.average_cost END`
+		if _, err := tx.Exec(query, b.UserID, b.Ticker, b.Expected.IntPart(decimal.Down)); err != nil {
+			return nil, err
+		}
+		breaks[i].Corrected = true
+	}
+	return breaks, nil
+}
+
+// taxLots compares every position with the sum of its open tax lots.
+// Breaks are corrected by replacing the lots with a single lot at the
+// position's average cost.
+func taxLots(tx *sql.Tx, correct bool) ([]Break, error) {
+	query := `
+		WITH lots AS (
+			SELECT user_id, ticker, SUM(quantity) AS quantity
+			FROM tax_lots
+			GROUP BY user_id, ticker
+		)
+		SELECT u.id, u.username, COALESCE(p.ticker, l.ticker), COALESCE(p.quantity, 0), COALESCE(l.quantity, 0)
+		FROM positions p
+		FULL JOIN lots l ON l.user_id = p.user_id AND l.ticker = p.ticker
+		INNER JOIN users u ON u.id = COALESCE(p.user_id, l.user_id)
+		WHERE COALESCE(p.quantity, 0) <> COALESCE(l.quantity, 0)
+		ORDER BY u.id, 3`
+	breaks, err := collect(tx, BreakTaxLots, query)
+	if err != nil || !correct {
+		return breaks, err
+	}
+
+	for i, b := range breaks {
+		if _, err := tx.Exec(`DELETE FROM tax_lots WHERE user_id = $1 AND ticker = $2`, b.UserID, b.Ticker); err != nil {
+			return nil, err
+		}
+		query := `
+			INSERT INTO tax_lots (user_id, ticker, quantity, cost)
+			SELECT user_id, ticker, quantity, average_cost FROM positions
+			WHERE user_id = $1 AND ticker = $2 AND quantity <> 0`
+		if _, err := tx.Exec(query, b.UserID, b.Ticker); err != nil {
+			return nil, err
+		}
+		breaks[i].Corrected = true
+	}
+	return breaks, nil
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"stock_exchange_Golang_project/controllers"
 	"stock_exchange_Golang_project/middleware"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -15,6 +16,10 @@ func ConfigureRoutes() *gin.Engine {
 
 	router.Use(middleware.DBMiddleware())
 
+	// Idempotency keys are scoped to the caller, so the middleware runs
+	// after AuthMiddleware on every authenticated group.
+	idempotency := middleware.IdempotencyMiddleware(24 * time.Hour)
+
 	router.GET("docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	authRoutes := router.Group("/user")
@@ -24,24 +29,96 @@ func ConfigureRoutes() *gin.Engine {
 		authRoutes.GET("/authenticated", middleware.AuthMiddleware, controllers.IsAuthenticated)
 	}
 
-	userRoutes := router.Group("/api/users")
+	userRoutes := router.Group("/api/users", middleware.AuthMiddleware, idempotency)
+	{
+		userRoutes.POST("/", controllers.CreateUser)
+		userRoutes.GET("/:username/", controllers.GetUser)
+		userRoutes.GET("/:username/positions", controllers.GetPositions)
+		userRoutes.GET("/:username/buying-power", controllers.GetBuyingPower)
+		userRoutes.GET("/:username/ledger", controllers.GetLedger)
+		userRoutes.GET("/:username/balances", controllers.GetCashBalances)
+		userRoutes.GET("/:username/portfolio", controllers.GetPortfolio)
+		userRoutes.PUT("/:username/cost-basis-method", controllers.SetCostBasisMethod)
+		userRoutes.GET("/:username/settlements", controllers.GetSettlements)
+		userRoutes.GET("/:username/transfers", controllers.GetUserTransfers)
+		userRoutes.POST("/:username/deposits", controllers.RequestDeposit)
+		userRoutes.POST("/:username/withdrawals", controllers.RequestWithdrawal)
+	}
+
+	router.GET("/api/stocks/", controllers.GetAllStocks)
+
+	stockRoutes := router.Group("/api/stocks", middleware.AuthMiddleware, idempotency)
+	{
+		stockRoutes.POST("/", middleware.AdminMiddleware, controllers.CreateStock)
+		stockRoutes.GET("/:ticker", controllers.GetStockByTicker)
+		stockRoutes.PUT("/:ticker", middleware.AdminMiddleware, controllers.ReplaceStock)
+		stockRoutes.PATCH("/:ticker", middleware.AdminMiddleware, controllers.UpdateStock)
+		stockRoutes.DELETE("/:ticker", middleware.AdminMiddleware, controllers.DelistStock)
+		stockRoutes.GET("/:ticker/history", controllers.GetStockHistory)
+		stockRoutes.PUT("/:ticker/margin", middleware.AdminMiddleware, controllers.SetStockMargin)
+		stockRoutes.GET("/:ticker/price-bands", controllers.GetPriceBands)
+		stockRoutes.PUT("/:ticker/price-bands", middleware.AdminMiddleware, controllers.SetPriceBands)
+		stockRoutes.GET("/:ticker/corporate-actions", controllers.GetCorporateActions)
+		stockRoutes.GET("/:ticker/candles", controllers.GetCandles)
+	}
+
+	eventRoutes := router.Group("/api/events", middleware.AuthMiddleware)
+	{
+		eventRoutes.GET("/", controllers.StreamEvents)
+	}
+
+	transactionRoutes := router.Group("/api/transactions", middleware.AuthMiddleware, idempotency)
+	{
+		transactionRoutes.POST("/", controllers.CreateTransaction)
+		transactionRoutes.GET("/:username/", controllers.GetTransactions)
+		transactionRoutes.GET("/:username/:start_time/:end_time/", controllers.GetTransactionsByDate)
+	}
+
+	orderRoutes := router.Group("/api/orders", middleware.AuthMiddleware, idempotency)
+	{
+		orderRoutes.GET("/", controllers.GetOrders)
+		orderRoutes.GET("/:id", controllers.GetOrder)
+		orderRoutes.DELETE("/:id", controllers.CancelOrder)
+		orderRoutes.PATCH("/:id", controllers.AmendOrder)
+	}
+
+	feeRoutes := router.Group("/api/fees", middleware.AuthMiddleware, idempotency)
+	{
+		feeRoutes.GET("/", controllers.GetFeeSchedules)
+		feeRoutes.POST("/", middleware.AdminMiddleware, controllers.SetFeeSchedule)
+		feeRoutes.GET("/revenue", controllers.GetFeeRevenue)
+		feeRoutes.DELETE("/:id", middleware.AdminMiddleware, controllers.DeleteFeeSchedule)
+	}
+
+	marketRoutes := router.Group("/api/market")
 	{
-		userRoutes.POST("/", middleware.AuthMiddleware, controllers.CreateUser)
-		userRoutes.GET("/:username/", middleware.AuthMiddleware, controllers.GetUser)
+		marketRoutes.GET("/status", controllers.GetMarketStatus)
+		marketRoutes.GET("/calendar", controllers.GetCalendar)
+		marketRoutes.GET("/stream", middleware.AuthMiddleware, controllers.StreamMarketData)
 	}
 
-	stockRoutes := router.Group("/api/stocks")
+	fxRoutes := router.Group("/api/fx-rates", middleware.AuthMiddleware)
 	{
-		stockRoutes.POST("/", middleware.AuthMiddleware, controllers.CreateStock)
-		stockRoutes.GET("/", controllers.GetAllStocks)
-		stockRoutes.GET("/:ticker", middleware.AuthMiddleware, controllers.GetStockByTicker)
+		fxRoutes.GET("/", controllers.GetFXRates)
 	}
 
-	transactionRoutes := router.Group("/api/transactions")
+	adminRoutes := router.Group("/api/admin", middleware.AuthMiddleware, idempotency)
 	{
-		transactionRoutes.POST("/", middleware.AuthMiddleware, controllers.CreateTransaction)
-		transactionRoutes.GET("/:username/", middleware.AuthMiddleware, controllers.GetTransactions)
-		transactionRoutes.GET("/:username/:start_time/:end_time/", middleware.AuthMiddleware, controllers.GetTransactionsByDate)
+		adminRoutes.PUT("/calendar", middleware.AdminMiddleware, controllers.UpdateCalendar)
+		adminRoutes.POST("/holidays", middleware.AdminMiddleware, controllers.AddHoliday)
+		adminRoutes.DELETE("/holidays/:date", middleware.AdminMiddleware, controllers.DeleteHoliday)
+		adminRoutes.POST("/halts", middleware.AdminMiddleware, controllers.HaltTrading)
+		adminRoutes.POST("/resume", middleware.AdminMiddleware, controllers.ResumeTrading)
+		adminRoutes.GET("/transfers", middleware.AdminMiddleware, controllers.GetTransfers)
+		adminRoutes.POST("/transfers/:id/approve", middleware.AdminMiddleware, controllers.ApproveTransfer)
+		adminRoutes.POST("/transfers/:id/reject", middleware.AdminMiddleware, controllers.RejectTransfer)
+		adminRoutes.PUT("/fx-rates/:currency", middleware.AdminMiddleware, controllers.SetFXRate)
+		adminRoutes.POST("/corporate-actions", middleware.AdminMiddleware, controllers.AnnounceCorporateAction)
+		adminRoutes.POST("/corporate-actions/process", middleware.AdminMiddleware, controllers.ProcessCorporateActions)
+		adminRoutes.DELETE("/corporate-actions/:id", middleware.AdminMiddleware, controllers.CancelCorporateAction)
+		adminRoutes.POST("/settlements/process", middleware.AdminMiddleware, controllers.SettleTrades)
+		adminRoutes.GET("/reconciliation", middleware.AdminMiddleware, controllers.Reconcile)
+		adminRoutes.POST("/reconciliation/corrections", middleware.AdminMiddleware, controllers.CorrectBreaks)
 	}
 
 	return router
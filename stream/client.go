@@ -0,0 +1,104 @@
+package stream
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait bounds how long writing one message may take.
+	writeWait = 10 * time.Second
+	// pongWait is how long a peer may go without answering a ping.
+	pongWait = 60 * time.Second
+	// pingPeriod is how often peers are pinged; it must be below pongWait.
+	pingPeriod = pongWait * 9 / 10
+	// maxCommandSize bounds the size of a message a client may send.
+	maxCommandSize = 4096
+)
+
+// command is a message sent by a client.
+type command struct {
+	Action  string   `json:"action"`
+	Tickers []string `json:"tickers"`
+}
+
+// client is one WebSocket connection. seq and send are owned by the hub;
+// closeReason is set by the hub before it closes send.
+type client struct {
+	conn        *websocket.Conn
+	username    string
+	send        chan Message
+	seq         uint64
+	closeReason string
+}
+
+// Serve streams market data over conn for username until the connection
+// closes. It blocks, reading the client's commands, while a second
+// goroutine writes to the connection.
+func Serve(conn *websocket.Conn, username string) {
+	c := &client{conn: conn, username: username, send: make(chan Message, sendBuffer)}
+	h.register <- c
+	go c.write()
+	c.read()
+}
+
+// read forwards the client's commands to the hub until the connection
+// fails, then unregisters the client.
+func (c *client) read() {
+	defer func() {
+		h.unregister <- c
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxCommandSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		// A message that is not a command is answered as an unknown action.
+		var cmd command
+		json.Unmarshal(data, &cmd)
+		h.requests <- request{client: c, action: cmd.Action, tickers: cmd.Tickers}
+	}
+}
+
+// write sends the client's queued messages and pings until the hub closes
+// the queue or a write fails.
+func (c *client) write() {
+	ping := time.NewTicker(pingPeriod)
+	defer func() {
+		ping.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				code, text := websocket.CloseNormalClosure, ""
+				if c.closeReason != "" {
+					code, text = websocket.CloseTryAgainLater, c.closeReason
+				}
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, text))
+				return
+			}
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,450 @@
+// Package stream serves live market data over WebSocket connections.
+// Clients subscribe to tickers by sending
+//
+//	{"action": "subscribe", "tickers": ["AAPL"]}
+//
+// and stop with the "unsubscribe" action. For every ticker subscribed to the
+// client first receives a snapshot of its quote and order book and then:
+//
+//   - tick: the last price or the best bid or ask changed.
+//   - trade: a trade printed.
+//   - book: price levels changed. Each delta carries the new open quantity
+//     of a level; zero removes it. Applying deltas to the snapshot in order
+//     keeps a local copy of the book.
+//
+// Snapshots and book messages carry book_seq, which numbers the versions of
+// a ticker's book in the order the exchange committed them: the deltas with
+// book_seq n turn the book with book_seq n-1 into version n. When the server
+// misses versions of a book, or receives them out of order, it sends a new
+// snapshot in place of deltas, which replaces the client's copy.
+//
+// Every message a connection receives is numbered by seq, consecutively
+// from 1. A heartbeat message is sent every 15 seconds, and WebSocket pings
+// detect dead peers. A client that does not keep up with its messages is
+// disconnected with close code 1013 rather than silently skipping any, so a
+// gap in seq never occurs on a live connection; clients reconnect and
+// resubscribe to get a fresh snapshot. Should the server itself fall behind
+// the exchange and miss events, every client is disconnected the same way.
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"stock_exchange_Golang_project/engine"
+	"stock_exchange_Golang_project/events"
+	"stock_exchange_Golang_project/utils/decimal"
+)
+
+const (
+	MessageSnapshot      = "snapshot"
+	MessageTick          = "tick"
+	MessageTrade         = "trade"
+	MessageBook          = "book"
+	MessageHeartbeat     = "heartbeat"
+	MessageSubscriptions = "subscriptions"
+	MessageError         = "error"
+)
+
+const (
+	// sendBuffer is how many messages may wait for a slow connection before
+	// it is disconnected.
+	sendBuffer = 256
+	// eventBuffer is how many events may wait for the hub.
+	eventBuffer = 4096
+	// heartbeatInterval is how often every connection receives a heartbeat.
+	heartbeatInterval = 15 * time.Second
+	// maxSubscriptions bounds the tickers one connection may subscribe to.
+	maxSubscriptions = 100
+)
+
+// Message is one message sent to a client.
+type Message struct {
+	Seq     uint64      `json:"seq"`
+	Type    string      `json:"type"`
+	Ticker  string      `json:"ticker,omitempty"`
+	BookSeq int64       `json:"book_seq,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Time    time.Time   `json:"time"`
+}
+
+// Quote is the last trade price of a ticker and the top of its book. Bid and
+// Ask are null while that side of the book is empty.
+type Quote struct {
+	LastPrice decimal.Decimal  `json:"last_price"`
+	Bid       *decimal.Decimal `json:"bid"`
+	BidSize   int              `json:"bid_size"`
+	Ask       *decimal.Decimal `json:"ask"`
+	AskSize   int              `json:"ask_size"`
+}
+
+// Snapshot is the state of a ticker when a client subscribes to it.
+type Snapshot struct {
+	Quote Quote        `json:"quote"`
+	Book  *engine.Book `json:"book"`
+}
+
+// Print is a trade as published to every subscriber; the parties are not
+// disclosed.
+type Print struct {
+	Price     decimal.Decimal `json:"price"`
+	Volume    int             `json:"volume"`
+	TakerSide string          `json:"taker_side"`
+}
+
+// Delta is the new state of one price level. Quantity and Orders are zero
+// when the level has emptied.
+type Delta struct {
+	Side     string          `json:"side"`
+	Price    decimal.Decimal `json:"price"`
+	Quantity int             `json:"quantity"`
+	Orders   int             `json:"orders"`
+}
+
+// request is a subscription change asked for by a client. Unknown actions
+// are answered with an error message.
+type request struct {
+	client  *client
+	action  string
+	tickers []string
+}
+
+// loaded is the outcome of loading the book of a ticker.
+type loaded struct {
+	ticker string
+	book   *engine.Book
+	err    error
+}
+
+// hub owns every connection and the last book seen of every ticker. loading
+// holds the clients waiting for the book of a ticker to be loaded. All of
+// its state is only touched by the goroutine running Run.
+type hub struct {
+	register   chan *client
+	unregister chan *client
+	requests   chan request
+	loaded     chan loaded
+	clients    map[*client]map[string]bool
+	books      map[string]*engine.Book
+	loading    map[string]map[*client]bool
+}
+
+var h = &hub{
+	register:   make(chan *client),
+	unregister: make(chan *client),
+	requests:   make(chan request),
+	loaded:     make(chan loaded),
+	clients:    map[*client]map[string]bool{},
+	books:      map[string]*engine.Book{},
+	loading:    map[string]map[*client]bool{},
+}
+
+// Run dispatches engine events to the connected clients until the process
+// exits. Snapshots are loaded from db.
+func Run(db *sql.DB) {
+	feed := events.Subscribe(eventBuffer)
+	defer feed.Unsubscribe()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case c := <-h.register:
+			h.clients[c] = map[string]bool{}
+		case c := <-h.unregister:
+			h.drop(c, "")
+		case req := <-h.requests:
+			h.handle(db, req)
+		case result := <-h.loaded:
+			h.finishLoad(result)
+		case event := <-feed.C:
+			if feed.Lost() {
+				h.reset()
+			}
+			h.dispatch(event)
+		case now := <-heartbeat.C:
+			for c := range h.clients {
+				h.deliver(c, Message{Type: MessageHeartbeat, Time: now})
+			}
+		}
+	}
+}
+
+// deliver numbers msg and queues it for c, disconnecting c when its queue
+// is full.
+func (h *hub) deliver(c *client, msg Message) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	c.seq++
+	msg.Seq = c.seq
+	select {
+	case c.send <- msg:
+	default:
+		log.Printf("Disconnecting slow stream client %s", c.username)
+		h.drop(c, "slow consumer")
+	}
+}
+
+// drop forgets c and closes its queue, which ends its writer. A non-empty
+// reason closes the connection with code 1013 and that reason.
+func (h *hub) drop(c *client, reason string) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	for _, waiting := range h.loading {
+		delete(waiting, c)
+	}
+	c.closeReason = reason
+	close(c.send)
+}
+
+// reset disconnects every client and forgets every book once the hub has
+// missed events, since it can no longer tell its clients everything that
+// happened.
+func (h *hub) reset() {
+	log.Printf("Market data stream missed events; disconnecting %d clients", len(h.clients))
+	for c := range h.clients {
+		h.drop(c, "missed events")
+	}
+	h.books = map[string]*engine.Book{}
+}
+
+func (h *hub) handle(db *sql.DB, req request) {
+	c := req.client
+	subscriptions, ok := h.clients[c]
+	if !ok {
+		return
+	}
+
+	switch req.action {
+	case "subscribe":
+		for _, ticker := range req.tickers {
+			if subscriptions[ticker] || h.loading[ticker][c] {
+				continue
+			}
+			if len(subscriptions)+h.pending(c) >= maxSubscriptions {
+				h.deliver(c, Message{Type: MessageError, Ticker: ticker, Data: "Too many subscriptions"})
+				continue
+			}
+			if book, ok := h.books[ticker]; ok {
+				subscriptions[ticker] = true
+				h.deliver(c, snapshot(book))
+				continue
+			}
+			h.load(db, ticker, c)
+		}
+	case "unsubscribe":
+		for _, ticker := range req.tickers {
+			delete(subscriptions, ticker)
+			delete(h.loading[ticker], c)
+		}
+	default:
+		h.deliver(c, Message{Type: MessageError, Data: "Unknown action; use subscribe or unsubscribe"})
+		return
+	}
+
+	h.deliver(c, subscriptionList(subscriptions))
+}
+
+// pending returns the number of tickers c waits for the book of.
+func (h *hub) pending(c *client) int {
+	n := 0
+	for _, waiting := range h.loading {
+		if waiting[c] {
+			n++
+		}
+	}
+	return n
+}
+
+// load fetches the book of ticker for c outside the hub goroutine, so that
+// the query does not hold up the other connections. Clients subscribing
+// while it runs wait for the same load.
+func (h *hub) load(db *sql.DB, ticker string, c *client) {
+	if waiting, ok := h.loading[ticker]; ok {
+		waiting[c] = true
+		return
+	}
+	h.loading[ticker] = map[*client]bool{c: true}
+	go func() {
+		book, err := loadBook(db, ticker)
+		h.loaded <- loaded{ticker: ticker, book: book, err: err}
+	}()
+}
+
+// finishLoad subscribes the clients waiting for a loaded book to its ticker
+// and sends them its snapshot.
+func (h *hub) finishLoad(result loaded) {
+	waiting := h.loading[result.ticker]
+	delete(h.loading, result.ticker)
+
+	if result.err == nil {
+		// A book raised while the load ran may be newer than the one loaded.
+		if cached, ok := h.books[result.ticker]; !ok || cached.Seq < result.book.Seq {
+			h.books[result.ticker] = result.book
+		}
+	} else if !errors.Is(result.err, engine.ErrStockNotFound) {
+		log.Printf("Failed to load book of %s: %v", result.ticker, result.err)
+	}
+
+	for c := range waiting {
+		subscriptions, ok := h.clients[c]
+		if !ok {
+			continue
+		}
+		switch {
+		case errors.Is(result.err, engine.ErrStockNotFound):
+			h.deliver(c, Message{Type: MessageError, Ticker: result.ticker, Data: "Stock not found"})
+		case result.err != nil:
+			h.deliver(c, Message{Type: MessageError, Ticker: result.ticker, Data: "Failed to load order book"})
+		default:
+			subscriptions[result.ticker] = true
+			h.deliver(c, snapshot(h.books[result.ticker]))
+		}
+		h.deliver(c, subscriptionList(subscriptions))
+	}
+}
+
+// loadBook reads the book of ticker and its sequence number as of one
+// moment.
+func loadBook(db *sql.DB, ticker string) (*engine.Book, error) {
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return engine.LoadBook(tx, ticker)
+}
+
+func snapshot(book *engine.Book) Message {
+	return Message{Type: MessageSnapshot, Ticker: book.Ticker, BookSeq: book.Seq, Data: Snapshot{Quote: quote(book), Book: book}}
+}
+
+func subscriptionList(subscriptions map[string]bool) Message {
+	tickers := []string{}
+	for ticker := range subscriptions {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	return Message{Type: MessageSubscriptions, Data: tickers}
+}
+
+// dispatch turns an engine event into messages for the subscribers of its
+// ticker.
+func (h *hub) dispatch(event events.Event) {
+	var messages []Message
+	switch event.Type {
+	case events.Trade:
+		fill, ok := event.Data.(engine.Fill)
+		if !ok {
+			return
+		}
+		messages = append(messages, Message{Type: MessageTrade, Ticker: event.Ticker, Time: event.Time,
+			Data: Print{Price: fill.Price, Volume: fill.Volume, TakerSide: fill.TakerSide}})
+	case events.Book:
+		book, ok := event.Data.(*engine.Book)
+		if !ok {
+			return
+		}
+		previous, seen := h.books[event.Ticker]
+		if seen && book.Seq <= previous.Seq {
+			// A newer version of the book has already been applied.
+			return
+		}
+		h.books[event.Ticker] = book
+		if !seen {
+			// Nobody has subscribed to the ticker yet, so nobody holds a
+			// book to apply deltas to.
+			return
+		}
+		if book.Seq == previous.Seq+1 {
+			messages = append(messages, Message{Type: MessageBook, Ticker: event.Ticker, BookSeq: book.Seq, Time: event.Time,
+				Data: diff(previous, book)})
+		} else {
+			// Versions in between were missed or have yet to arrive, so the
+			// deltas would not apply to the clients' copies.
+			msg := snapshot(book)
+			msg.Time = event.Time
+			messages = append(messages, msg)
+		}
+		if before, after := quote(previous), quote(book); !before.equal(after) {
+			messages = append(messages, Message{Type: MessageTick, Ticker: event.Ticker, Time: event.Time, Data: after})
+		}
+	default:
+		return
+	}
+
+	for c, subscriptions := range h.clients {
+		if !subscriptions[event.Ticker] {
+			continue
+		}
+		for _, msg := range messages {
+			h.deliver(c, msg)
+		}
+	}
+}
+
+func quote(book *engine.Book) Quote {
+	q := Quote{LastPrice: book.LastPrice}
+	if len(book.Bids) > 0 {
+		q.Bid, q.BidSize = &book.Bids[0].Price, book.Bids[0].Quantity
+	}
+	if len(book.Asks) > 0 {
+		q.Ask, q.AskSize = &book.Asks[0].Price, book.Asks[0].Quantity
+	}
+	return q
+}
+
+func (q Quote) equal(r Quote) bool {
+	return q.LastPrice.Equal(r.LastPrice) && equalPrice(q.Bid, r.Bid) && q.BidSize == r.BidSize &&
+		equalPrice(q.Ask, r.Ask) && q.AskSize == r.AskSize
+}
+
+func equalPrice(p, q *decimal.Decimal) bool {
+	if p == nil || q == nil {
+		return p == q
+	}
+	return p.Equal(*q)
+}
+
+// diff returns the levels of after that differ from before, followed by the
+// levels of before that after no longer has.
+func diff(before, after *engine.Book) []Delta {
+	deltas := []Delta{}
+	for _, side := range []struct {
+		name          string
+		before, after []engine.Level
+	}{
+		{engine.Buy, before.Bids, after.Bids},
+		{engine.Sell, before.Asks, after.Asks},
+	} {
+		old := map[string]engine.Level{}
+		for _, level := range side.before {
+			old[level.Price.String()] = level
+		}
+		for _, level := range side.after {
+			previous, ok := old[level.Price.String()]
+			delete(old, level.Price.String())
+			if ok && previous.Quantity == level.Quantity && previous.Orders == level.Orders {
+				continue
+			}
+			deltas = append(deltas, Delta{Side: side.name, Price: level.Price, Quantity: level.Quantity, Orders: level.Orders})
+		}
+		for _, level := range side.before {
+			if _, ok := old[level.Price.String()]; ok {
+				deltas = append(deltas, Delta{Side: side.name, Price: level.Price})
+			}
+		}
+	}
+	return deltas
+}
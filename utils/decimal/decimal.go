@@ -0,0 +1,392 @@
+// Package decimal implements exact fixed-point numbers for prices, cash
+// amounts and rates. Every value carries Scale fractional digits, so sums
+// and differences are always exact; products and quotients that need more
+// digits are rounded with an explicit RoundingMode. Values are immutable and
+// the zero value is 0.
+//
+// Decimals are written to JSON as strings so that clients never parse them
+// into binary floating point, and are read from JSON strings or numbers.
+// They are stored in Postgres NUMERIC columns.
+package decimal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of fractional digits every Decimal carries.
+const Scale = 8
+
+// Cents is the number of fractional digits of prices and cash amounts.
+const Cents = 2
+
+// MaxIntegerDigits is the number of integer digits Parse accepts, the most
+// any NUMERIC column of the exchange holds.
+const MaxIntegerDigits = 12
+
+// maxExponent bounds the exponent of parsed numbers and maxDigits their
+// significant digits, so that hostile input such as "1e30000000" cannot
+// make parsing build huge numbers.
+const (
+	maxExponent = 30
+	maxDigits   = 64
+)
+
+// RoundingMode decides how digits dropped by Round, Mul and Div are
+// rounded.
+type RoundingMode int
+
+const (
+	// HalfEven rounds to the nearest value and ties to the even neighbour.
+	HalfEven RoundingMode = iota
+	// HalfUp rounds to the nearest value and ties away from zero.
+	HalfUp
+	// Down rounds towards zero.
+	Down
+	// Up rounds away from zero.
+	Up
+	// Floor rounds towards negative infinity.
+	Floor
+	// Ceiling rounds towards positive infinity.
+	Ceiling
+)
+
+var ErrInvalid = errors.New("invalid decimal")
+
+// Decimal is a signed number with Scale fractional digits.
+type Decimal struct {
+	// units is the value multiplied by 10^Scale. nil means zero.
+	units *big.Int
+}
+
+// Zero is the decimal 0.
+var Zero = Decimal{}
+
+var (
+	one         = big.NewInt(1)
+	ten         = big.NewInt(10)
+	scaleFactor = pow10(Scale)
+	maxUnits    = pow10(MaxIntegerDigits + Scale)
+	maxInt      = big.NewInt(math.MaxInt)
+	minInt      = big.NewInt(math.MinInt)
+)
+
+// New returns value × 10^exp, so New(15025, -2) is 150.25. Digits beyond
+// Scale are rounded half to even.
+func New(value int64, exp int) Decimal {
+	return fromDigits(big.NewInt(value), exp, HalfEven)
+}
+
+// FromInt returns the decimal value of n.
+func FromInt(n int) Decimal {
+	return New(int64(n), 0)
+}
+
+// Parse reads a decimal such as "150.25", "-0.003" or "1e3". It fails when
+// s has more than Scale fractional digits rather than rounding them away,
+// and when its integer part has more than MaxIntegerDigits digits.
+func Parse(s string) (Decimal, error) {
+	digits, exp, err := parse(s)
+	if err != nil {
+		return Zero, err
+	}
+	if exp+Scale < 0 {
+		if _, r := new(big.Int).QuoRem(digits, pow10(-(exp + Scale)), new(big.Int)); r.Sign() != 0 {
+			return Zero, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalid, s, Scale)
+		}
+	}
+	d := fromDigits(digits, exp, HalfEven)
+	if new(big.Int).Abs(d.int()).Cmp(maxUnits) >= 0 {
+		return Zero, fmt.Errorf("%w: %q has more than %d integer digits", ErrInvalid, s, MaxIntegerDigits)
+	}
+	return d, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for constants.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// parse splits s into its significant digits and decimal exponent. Numbers
+// with an exponent outside ±maxExponent or more than maxDigits digits are
+// rejected.
+func parse(s string) (*big.Int, int, error) {
+	s = strings.TrimSpace(s)
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		mantissa = s[:i]
+		if exp, err = strconv.Atoi(s[i+1:]); err != nil || exp > maxExponent || exp < -maxExponent {
+			return nil, 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+		}
+	}
+
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	if whole+fraction == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return nil, 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	if len(strings.TrimLeft(whole+fraction, "0")) > maxDigits || len(fraction) > maxDigits {
+		return nil, 0, fmt.Errorf("%w: %q has too many digits", ErrInvalid, s)
+	}
+
+	digits, _ := new(big.Int).SetString(sign+whole+fraction, 10)
+	return digits, exp - len(fraction), nil
+}
+
+// fromDigits returns digits × 10^exp rounded to Scale with mode.
+func fromDigits(digits *big.Int, exp int, mode RoundingMode) Decimal {
+	shift := exp + Scale
+	if shift >= 0 {
+		return Decimal{new(big.Int).Mul(digits, pow10(shift))}
+	}
+	return Decimal{quo(digits, pow10(-shift), mode)}
+}
+
+func (d Decimal) int() *big.Int {
+	if d.units == nil {
+		return new(big.Int)
+	}
+	return d.units
+}
+
+// Add returns d + e.
+func (d Decimal) Add(e Decimal) Decimal {
+	return Decimal{new(big.Int).Add(d.int(), e.int())}
+}
+
+// Sub returns d - e.
+func (d Decimal) Sub(e Decimal) Decimal {
+	return Decimal{new(big.Int).Sub(d.int(), e.int())}
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{new(big.Int).Neg(d.int())}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{new(big.Int).Abs(d.int())}
+}
+
+// Mul returns d × e rounded half to even to Scale digits.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{quo(new(big.Int).Mul(d.int(), e.int()), scaleFactor, HalfEven)}
+}
+
+// MulInt returns d × n, which is always exact.
+func (d Decimal) MulInt(n int) Decimal {
+	return Decimal{new(big.Int).Mul(d.int(), big.NewInt(int64(n)))}
+}
+
+// Div returns d / e rounded to Scale digits with mode. It panics when e is
+// zero.
+func (d Decimal) Div(e Decimal, mode RoundingMode) Decimal {
+	if e.IsZero() {
+		panic("decimal: division by zero")
+	}
+	return Decimal{quo(new(big.Int).Mul(d.int(), scaleFactor), e.int(), mode)}
+}
+
+// DivInt returns d / n rounded to Scale digits with mode. It panics when n
+// is zero.
+func (d Decimal) DivInt(n int, mode RoundingMode) Decimal {
+	return d.Div(FromInt(n), mode)
+}
+
+// Round returns d rounded to places fractional digits with mode.
+func (d Decimal) Round(places int, mode RoundingMode) Decimal {
+	if places >= Scale {
+		return d
+	}
+	factor := pow10(Scale - places)
+	return Decimal{new(big.Int).Mul(quo(d.int(), factor, mode), factor)}
+}
+
+// IntPart returns d rounded to a whole number with mode. Values beyond the
+// range of int saturate at math.MaxInt or math.MinInt.
+func (d Decimal) IntPart(mode RoundingMode) int {
+	n := quo(d.int(), scaleFactor, mode)
+	switch {
+	case n.Cmp(maxInt) > 0:
+		return math.MaxInt
+	case n.Cmp(minInt) < 0:
+		return math.MinInt
+	}
+	return int(n.Int64())
+}
+
+// Cmp returns -1, 0 or +1 as d is less than, equal to or greater than e.
+func (d Decimal) Cmp(e Decimal) int {
+	return d.int().Cmp(e.int())
+}
+
+// Sign returns -1, 0 or +1 as d is negative, zero or positive.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+func (d Decimal) Equal(e Decimal) bool       { return d.Cmp(e) == 0 }
+func (d Decimal) LessThan(e Decimal) bool    { return d.Cmp(e) < 0 }
+func (d Decimal) GreaterThan(e Decimal) bool { return d.Cmp(e) > 0 }
+func (d Decimal) IsZero() bool               { return d.Sign() == 0 }
+func (d Decimal) IsPositive() bool           { return d.Sign() > 0 }
+func (d Decimal) IsNegative() bool           { return d.Sign() < 0 }
+
+// Min returns the smaller of d and e.
+func Min(d, e Decimal) Decimal {
+	if d.LessThan(e) {
+		return d
+	}
+	return e
+}
+
+// Max returns the larger of d and e.
+func Max(d, e Decimal) Decimal {
+	if d.GreaterThan(e) {
+		return d
+	}
+	return e
+}
+
+// String formats d without trailing fractional zeros, such as "150.25",
+// "0.003" or "1000".
+func (d Decimal) String() string {
+	s := d.StringFixed(Scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// StringFixed formats d with exactly places fractional digits, rounding
+// half to even when d has more.
+func (d Decimal) StringFixed(places int) string {
+	places = min(max(places, 0), Scale)
+	rounded := d.Round(places, HalfEven)
+	whole, fraction := new(big.Int).QuoRem(new(big.Int).Abs(rounded.int()), scaleFactor, new(big.Int))
+
+	var b strings.Builder
+	if rounded.IsNegative() {
+		b.WriteByte('-')
+	}
+	b.WriteString(whole.String())
+	if places > 0 {
+		digits := fraction.String()
+		digits = strings.Repeat("0", Scale-len(digits)) + digits
+		b.WriteByte('.')
+		b.WriteString(digits[:places])
+	}
+	return b.String()
+}
+
+// MarshalJSON writes d as a JSON string.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON reads d from a JSON string or number. null leaves d
+// unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan reads d from a database column. Values with more than Scale
+// fractional digits, such as the result of a NUMERIC division, are rounded
+// half to even.
+func (d *Decimal) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*d = New(v, 0)
+		return nil
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("decimal: cannot scan %T", src)
+	}
+
+	digits, exp, err := parse(s)
+	if err != nil {
+		return err
+	}
+	*d = fromDigits(digits, exp, HalfEven)
+	return nil
+}
+
+// Value writes d to a database column.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// quo returns n / m rounded to an integer with mode.
+func quo(n, m *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(n, m, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	sign := n.Sign() * m.Sign()
+	half := new(big.Int).Abs(r)
+	half.Lsh(half, 1)
+	tie := half.Cmp(new(big.Int).Abs(m))
+
+	var away bool
+	switch mode {
+	case HalfEven:
+		away = tie > 0 || (tie == 0 && q.Bit(0) == 1)
+	case HalfUp:
+		away = tie >= 0
+	case Down:
+		away = false
+	case Up:
+		away = true
+	case Floor:
+		away = sign < 0
+	case Ceiling:
+		away = sign > 0
+	}
+
+	if away {
+		if sign < 0 {
+			q.Sub(q, one)
+		} else {
+			q.Add(q, one)
+		}
+	}
+	return q
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
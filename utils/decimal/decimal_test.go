@@ -0,0 +1,221 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestQuo(t *testing.T) {
+	tests := []struct {
+		n, m int64
+		want map[RoundingMode]int64
+	}{
+		{5, 2, map[RoundingMode]int64{HalfEven: 2, HalfUp: 3, Down: 2, Up: 3, Floor: 2, Ceiling: 3}},
+		{7, 2, map[RoundingMode]int64{HalfEven: 4, HalfUp: 4, Down: 3, Up: 4, Floor: 3, Ceiling: 4}},
+		{-5, 2, map[RoundingMode]int64{HalfEven: -2, HalfUp: -3, Down: -2, Up: -3, Floor: -3, Ceiling: -2}},
+		{-7, 2, map[RoundingMode]int64{HalfEven: -4, HalfUp: -4, Down: -3, Up: -4, Floor: -4, Ceiling: -3}},
+		{5, -2, map[RoundingMode]int64{HalfEven: -2, HalfUp: -3, Down: -2, Up: -3, Floor: -3, Ceiling: -2}},
+		{7, 3, map[RoundingMode]int64{HalfEven: 2, HalfUp: 2, Down: 2, Up: 3, Floor: 2, Ceiling: 3}},
+		{8, 3, map[RoundingMode]int64{HalfEven: 3, HalfUp: 3, Down: 2, Up: 3, Floor: 2, Ceiling: 3}},
+		{-8, 3, map[RoundingMode]int64{HalfEven: -3, HalfUp: -3, Down: -2, Up: -3, Floor: -3, Ceiling: -2}},
+		{6, 3, map[RoundingMode]int64{HalfEven: 2, HalfUp: 2, Down: 2, Up: 2, Floor: 2, Ceiling: 2}},
+	}
+	for _, tt := range tests {
+		for mode, want := range tt.want {
+			got := quo(big.NewInt(tt.n), big.NewInt(tt.m), mode)
+			if got.Int64() != want {
+				t.Errorf("quo(%d, %d, %d) = %s, want %d", tt.n, tt.m, mode, got, want)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"150.25", "150.25"},
+		{"-0.003", "-0.003"},
+		{"+7", "7"},
+		{"1e3", "1000"},
+		{"1.5E-2", "0.015"},
+		{"0.00000001", "0.00000001"},
+		{"1e-8", "0.00000001"},
+		{"150.2500000000", "150.25"},
+		{"999999999999.99999999", "999999999999.99999999"},
+		{"-999999999999", "-999999999999"},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		in     string
+		reason string
+	}{
+		{"", ""},
+		{"abc", ""},
+		{"1.2.3", ""},
+		{"1e", ""},
+		{"0.000000001", "decimal places"},
+		{"1.123456789", "decimal places"},
+		{"1e-9", "decimal places"},
+		{"1000000000000", "integer digits"},
+		{"-1e12", "integer digits"},
+		{"1e30", "integer digits"},
+		{"1e31", ""},
+		{"1e-31", ""},
+		{"1e30000000", ""},
+		{"1e-30000000", ""},
+		{"1" + strings.Repeat("0", 100), "too many digits"},
+		{"0." + strings.Repeat("0", 100), "too many digits"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.in)
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalid", tt.in, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.reason) {
+			t.Errorf("Parse(%q) error = %v, want it to mention %q", tt.in, err, tt.reason)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		in     string
+		places int
+		mode   RoundingMode
+		want   string
+	}{
+		{"2.345", 2, HalfEven, "2.34"},
+		{"2.355", 2, HalfEven, "2.36"},
+		{"2.345", 2, HalfUp, "2.35"},
+		{"-2.345", 2, HalfUp, "-2.35"},
+		{"2.349", 2, Down, "2.34"},
+		{"2.341", 2, Up, "2.35"},
+		{"-2.341", 2, Floor, "-2.35"},
+		{"-2.349", 2, Ceiling, "-2.34"},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.in).Round(tt.places, tt.mode); got.String() != tt.want {
+			t.Errorf("%s.Round(%d, %d) = %s, want %s", tt.in, tt.places, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestIntPart(t *testing.T) {
+	tests := []struct {
+		in   Decimal
+		mode RoundingMode
+		want int
+	}{
+		{MustParse("2.5"), HalfEven, 2},
+		{MustParse("2.5"), HalfUp, 3},
+		{MustParse("-2.7"), Down, -2},
+		{MustParse("-2.2"), Floor, -3},
+		{New(1, 30), Down, math.MaxInt},
+		{New(-1, 30), Down, math.MinInt},
+	}
+	for _, tt := range tests {
+		if got := tt.in.IntPart(tt.mode); got != tt.want {
+			t.Errorf("%s.IntPart(%d) = %d, want %d", tt.in, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`"150.25"`, "150.25"},
+		{`150.25`, "150.25"},
+		{`-3`, "-3"},
+		{`"1e2"`, "100"},
+		{`null`, "42"},
+	}
+	for _, tt := range tests {
+		d := FromInt(42)
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("Unmarshal(%s) failed: %v", tt.in, err)
+			continue
+		}
+		if d.String() != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, d, tt.want)
+		}
+
+		encoded, err := json.Marshal(d)
+		if err != nil {
+			t.Errorf("Marshal(%s) failed: %v", d, err)
+			continue
+		}
+		var back Decimal
+		if err := json.Unmarshal(encoded, &back); err != nil || !back.Equal(d) {
+			t.Errorf("round trip of %s gave %s (%v)", d, back, err)
+		}
+	}
+
+	for _, in := range []string{`"abc"`, `"1e30000000"`, `1.000000001`, `true`} {
+		var d Decimal
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Unmarshal(%s) = %s, want an error", in, d)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want string
+	}{
+		{[]byte("150.25"), "150.25"},
+		{"-0.5", "-0.5"},
+		{int64(12), "12"},
+		{float64(0.25), "0.25"},
+		{"0.333333333333333333", "0.33333333"},
+		{"0.666666666666666666", "0.66666667"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := d.Scan(tt.src); err != nil {
+			t.Errorf("Scan(%v) failed: %v", tt.src, err)
+			continue
+		}
+		if d.String() != tt.want {
+			t.Errorf("Scan(%v) = %s, want %s", tt.src, d, tt.want)
+		}
+
+		value, err := d.Value()
+		if err != nil {
+			t.Errorf("Value(%s) failed: %v", d, err)
+			continue
+		}
+		var back Decimal
+		if err := back.Scan(value); err != nil || !back.Equal(d) {
+			t.Errorf("round trip of %s gave %s (%v)", d, back, err)
+		}
+	}
+
+	for _, src := range []interface{}{true, "abc", "1e30000000"} {
+		var d Decimal
+		if err := d.Scan(src); err == nil {
+			t.Errorf("Scan(%v) = %s, want an error", src, d)
+		}
+	}
+}